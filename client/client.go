@@ -18,20 +18,22 @@
 package client
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"net/http"
 	"os"
 	"reflect"
 	"runtime/debug"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -44,15 +46,23 @@ import (
 var (
 	responseDPS    = make([]shared.DP, 0)
 	responseERR    = make([]shared.TError, 0)
+	responseHosts  = make([]shared.HostDP, 0)
 	responseLock   = sync.Mutex{}
 	websockets     []*wsClient
 	hostsDoingWork atomic.Int32
+	tlsConfig      *tls.Config
 )
 
 type wsClient struct {
 	ID   int
 	Host string
 	Con  *websocket.Conn
+	Info *shared.ServerInfo
+
+	// answers to clock exchanges and the estimated
+	// offset of the server clock in nanoseconds
+	clock       chan shared.ClockSample
+	clockOffset atomic.Int64
 }
 
 func (c *wsClient) SendError(e error) error {
@@ -70,8 +80,8 @@ func (c *wsClient) Close() (err error) {
 }
 
 func (c *wsClient) Remove() (err error) {
-	err = c.Con.Close()
 	websockets[c.ID] = nil
+	err = c.Con.Close()
 	return
 }
 
@@ -99,6 +109,11 @@ func (c *wsClient) NewSignal(signal shared.SignalType, conf shared.Config) *shar
 	msg := new(shared.WebsocketSignal)
 	msg.SType = signal
 	msg.Config = conf
+	msg.ProtocolVersion = shared.ProtocolVersion
+	err := shared.SignSignal(msg, conf.AuthKey)
+	if err != nil {
+		PrintError(err)
+	}
 	return msg
 }
 
@@ -112,11 +127,21 @@ func (c *wsClient) Ping() (err error) {
 var (
 	testList = make(map[string]shared.TestInfo)
 	testLock = sync.Mutex{}
+
+	statusList = make(map[string][]shared.TestStatusReport)
+	statusLock = sync.Mutex{}
 )
 
 func initializeClient(ctx context.Context, c *shared.Config) (err error) {
 	websockets = make([]*wsClient, len(c.Hosts))
 
+	if !c.Insecure {
+		tlsConfig, err = shared.NewTLSConfig(c.TLSCA, c.TLSFingerprints)
+		if err != nil {
+			return
+		}
+	}
+
 	clientID := 0
 	done := make(chan struct{}, len(c.Hosts))
 	for _, host := range c.Hosts {
@@ -154,7 +179,8 @@ func handleWSConnection(ctx context.Context, c *shared.Config, host string, id i
 			hostsDoingWork.Add(-1)
 			return
 		}
-		if c.RestartOnError && err != nil {
+		// hosts removed on purpose are not reconnected
+		if c.RestartOnError && err != nil && websockets[id] != nil {
 			time.Sleep(500 * time.Millisecond)
 			go handleWSConnection(ctx, c, host, id, done)
 		} else {
@@ -167,6 +193,7 @@ func handleWSConnection(ctx context.Context, c *shared.Config, host string, id i
 		websockets[id] = new(wsClient)
 		socket = websockets[id]
 		socket.ID = id
+		socket.clock = make(chan shared.ClockSample, 1)
 	}
 
 	socket.Host = host
@@ -176,6 +203,7 @@ func handleWSConnection(ctx context.Context, c *shared.Config, host string, id i
 		HandshakeTimeout: time.Second * c.DialTimeout,
 		ReadBufferSize:   1000000,
 		WriteBufferSize:  1000000,
+		TLSClientConfig:  tlsConfig,
 	}
 
 	shared.DEBUG(WarningStyle.Render("Connecting to ", host, ":", c.Port))
@@ -208,6 +236,7 @@ func handleWSConnection(ctx context.Context, c *shared.Config, host string, id i
 		PrintError(err)
 		return
 	}
+	socket.Info = msg.Info
 	shared.DEBUG(SuccessStyle.Render("Connected to ", host, ":", c.Port))
 
 	done <- struct{}{}
@@ -215,22 +244,32 @@ func handleWSConnection(ctx context.Context, c *shared.Config, host string, id i
 		signal := new(shared.WebsocketSignal)
 		err = con.ReadJSON(&signal)
 		if err != nil {
-			PrintError(err)
+			if websockets[id] != nil {
+				PrintError(err)
+			}
 			return
 		}
+		received := time.Now()
 		if shared.DebugEnabled {
 			fmt.Printf("WebsocketSignal: %+v\n", signal)
 		}
 		switch signal.SType {
 		case shared.Stats:
-			go collectDataPointv2(signal.DataPoint)
+			go collectDataPointv2(signal.DataPoint, socket.ClockOffset())
 		case shared.ListTests:
 			go parseTestList(signal.TestList)
+		case shared.TestStatus:
+			go parseTestStatus(host, signal.Statuses)
 		case shared.GetTest:
-			go receiveJSONDataPoint(signal.Data, c)
+			go receiveJSONDataPoint(signal.Data, socket.ClockOffset())
+		case shared.ClockSync:
+			socket.receiveClockSample(signal.Clock, received)
 		case shared.Err:
 			go PrintErrorString(signal.Error)
 		case shared.Done:
+			if signal.Reason != "" {
+				fmt.Println(WarningStyle.Render(fmt.Sprintf("Host %s finished: %s", host, signal.Reason)))
+			}
 			shared.DEBUG(SuccessStyle.Render("Host Finished: ", con.RemoteAddr().String()))
 			return
 		}
@@ -252,7 +291,9 @@ func PrintError(err error) {
 	fmt.Println(ErrorStyle.Render("ERROR: ", err.Error()))
 }
 
-func receiveJSONDataPoint(data []byte, _ *shared.Config) {
+// receiveJSONDataPoint parses a line of a test file, timestamps
+// are corrected by the clock offset of the server.
+func receiveJSONDataPoint(data []byte, offset time.Duration) {
 	responseLock.Lock()
 	defer responseLock.Unlock()
 
@@ -263,6 +304,7 @@ func receiveJSONDataPoint(data []byte, _ *shared.Config) {
 			PrintError(err)
 			return
 		}
+		dp.Created = dp.Created.Add(-offset)
 		responseERR = append(responseERR, *dp)
 	} else if bytes.HasPrefix(data, shared.DataPoint.String()) {
 		dp := new(shared.DP)
@@ -271,7 +313,26 @@ func receiveJSONDataPoint(data []byte, _ *shared.Config) {
 			PrintError(err)
 			return
 		}
+		dp.Created = dp.Created.Add(-offset)
 		responseDPS = append(responseDPS, *dp)
+	} else if bytes.HasPrefix(data, shared.HostPoint.String()) {
+		h := new(shared.HostDP)
+		err := json.Unmarshal(data[1:], &h)
+		if err != nil {
+			PrintError(err)
+			return
+		}
+		h.Created = h.Created.Add(-offset)
+		responseHosts = append(responseHosts, *h)
+	} else if bytes.HasPrefix(data, shared.MetadataPoint.String()) {
+		m := new(shared.TestMetadata)
+		err := json.Unmarshal(data[1:], &m)
+		if err != nil {
+			PrintError(err)
+			return
+		}
+		correctMetadata(m, offset)
+		responseMeta = append(responseMeta, *m)
 	} else {
 		PrintError(fmt.Errorf("Uknown data point: %s", data))
 	}
@@ -303,6 +364,33 @@ func keepAliveLoop(ctx context.Context, c *shared.Config, tickerfunc func() (sho
 	return ctx.Err()
 }
 
+// incompatibleHosts returns an error for every host which
+// can not handle the signal or the configured test type.
+func incompatibleHosts(signal shared.SignalType, c *shared.Config) (list map[string]error) {
+	list = make(map[string]error)
+	itterateWebsockets(func(ws *wsClient) {
+		err := shared.CheckCompatibility(ws.Info, signal, c.TestType, c.Direction)
+		if err != nil {
+			list[ws.Host] = err
+		}
+	})
+	return
+}
+
+// skipIncompatibleHosts closes the connection to hosts which do not support
+// the signal so the command can continue with the remaining hosts.
+func skipIncompatibleHosts(signal shared.SignalType, c *shared.Config) {
+	incompatible := incompatibleHosts(signal, c)
+	itterateWebsockets(func(ws *wsClient) {
+		err, ok := incompatible[ws.Host]
+		if !ok {
+			return
+		}
+		fmt.Println(WarningStyle.Render(fmt.Sprintf("Skipping host %s: %s", ws.Host, err)))
+		ws.Close()
+	})
+}
+
 func Listen(ctx context.Context, c shared.Config) (err error) {
 	cancelContext, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -311,6 +399,7 @@ func Listen(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	skipIncompatibleHosts(shared.ListenTest, &c)
 	itterateWebsockets(func(ws *wsClient) {
 		err = ws.Con.WriteJSON(ws.NewSignal(shared.ListenTest, c))
 		if err != nil {
@@ -329,6 +418,7 @@ func Stop(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	skipIncompatibleHosts(shared.StopAllTests, &c)
 	itterateWebsockets(func(ws *wsClient) {
 		err = ws.Con.WriteJSON(ws.NewSignal(shared.StopAllTests, c))
 		if err != nil {
@@ -339,6 +429,25 @@ func Stop(ctx context.Context, c shared.Config) (err error) {
 	return keepAliveLoop(ctx, &c, nil)
 }
 
+func Shutdown(ctx context.Context, c shared.Config) (err error) {
+	cancelContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err = initializeClient(cancelContext, &c)
+	if err != nil {
+		return
+	}
+
+	skipIncompatibleHosts(shared.Shutdown, &c)
+	itterateWebsockets(func(ws *wsClient) {
+		err = ws.Con.WriteJSON(ws.NewSignal(shared.Shutdown, c))
+		if err != nil {
+			return
+		}
+	})
+
+	return keepAliveLoop(ctx, &c, nil)
+}
+
 func RunTest(ctx context.Context, c shared.Config) (err error) {
 	cancelContext, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -347,6 +456,17 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	incompatible := incompatibleHosts(shared.RunTest, &c)
+	if len(incompatible) > 0 {
+		for _, host := range c.Hosts {
+			if err, ok := incompatible[host]; ok {
+				PrintError(fmt.Errorf("%s: %s", host, err))
+			}
+		}
+		return fmt.Errorf("%d of %d hosts are not compatible with this client, not starting the test", len(incompatible), len(c.Hosts))
+	}
+	syncClocks(&c)
+
 	ogh := slices.Clone(c.Hosts)
 	itterateWebsockets(func(ws *wsClient) {
 		oh := slices.Clone(ogh)
@@ -359,6 +479,8 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 	c.Hosts = ogh
 
 	printCount := 0
+	// data points before this index have been checked for CPU saturation
+	checkedDPS := 0
 
 	printOnTick := func() bool {
 		if len(responseDPS) == 0 {
@@ -371,6 +493,9 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 		to.TXL = math.MaxInt64
 		to.RMSL = math.MaxInt64
 		to.TTFBL = math.MaxInt64
+		to.JL = math.MaxInt64
+		to.CPSL = math.MaxUint64
+		to.MTUL = math.MaxInt
 		to.ML = responseDPS[0].MemoryUsedPercent
 		to.CL = responseDPS[0].CPUUsedPercent
 		tt := responseDPS[0].Type
@@ -378,6 +503,15 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 		for i := range responseDPS {
 			to.TXC += responseDPS[i].TXCount
 			to.TXT += responseDPS[i].TXTotal
+			to.RXC += responseDPS[i].PacketsReceived
+			to.LC += responseDPS[i].PacketsLost
+			to.ROC += responseDPS[i].PacketsReordered
+			to.DUPC += responseDPS[i].PacketsDuplicate
+			to.RTC += responseDPS[i].TCPRetransmits
+			to.PEC += responseDPS[i].ConnPortExhausted
+			to.TOC += responseDPS[i].ConnTimeouts
+			to.RFC += responseDPS[i].ConnRefused
+			to.RSC += responseDPS[i].ConnReset
 
 			if to.DP < responseDPS[i].DroppedPackets {
 				to.DP = responseDPS[i].DroppedPackets
@@ -392,6 +526,9 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 			if to.TTFBL > responseDPS[i].TTFBL {
 				to.TTFBL = responseDPS[i].TTFBL
 			}
+			if to.JL > responseDPS[i].Jitter {
+				to.JL = responseDPS[i].Jitter
+			}
 			if to.ML > responseDPS[i].MemoryUsedPercent {
 				to.ML = responseDPS[i].MemoryUsedPercent
 			}
@@ -408,12 +545,37 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 			if to.TTFBH < responseDPS[i].TTFBH {
 				to.TTFBH = responseDPS[i].TTFBH
 			}
+			if to.JH < responseDPS[i].Jitter {
+				to.JH = responseDPS[i].Jitter
+			}
+			if to.RTTH < responseDPS[i].TCPRTT {
+				to.RTTH = responseDPS[i].TCPRTT
+			}
 			if to.MH < responseDPS[i].MemoryUsedPercent {
 				to.MH = responseDPS[i].MemoryUsedPercent
 			}
 			if to.CH < responseDPS[i].CPUUsedPercent {
 				to.CH = responseDPS[i].CPUUsedPercent
 			}
+			if to.CCH < responseDPS[i].CPUCoreHigh {
+				to.CCH = responseDPS[i].CPUCoreHigh
+			}
+			if to.PCH < responseDPS[i].ProcessCPU {
+				to.PCH = responseDPS[i].ProcessCPU
+			}
+			if to.CPSH < responseDPS[i].ConnsPerSecond {
+				to.CPSH = responseDPS[i].ConnsPerSecond
+			}
+			if to.CPSL > responseDPS[i].ConnsPerSecond {
+				to.CPSL = responseDPS[i].ConnsPerSecond
+			}
+			if responseDPS[i].Type == shared.MTUTest {
+				to.MTUL = min(to.MTUL, responseDPS[i].PathMTU)
+				to.MTUH = max(to.MTUH, responseDPS[i].PathMTU)
+			}
+			if to.CTH < responseDPS[i].Phases.ConnectHigh() {
+				to.CTH = responseDPS[i].Phases.ConnectHigh()
+			}
 		}
 
 		if !c.Micro {
@@ -432,12 +594,41 @@ func RunTest(ctx context.Context, c shared.Config) (err error) {
 		}
 		printRealTimeRow(BaseStyle, to, tt)
 
+		printCPUSaturation(responseDPS[checkedDPS:])
+		checkedDPS = len(responseDPS)
+
 		return false
 	}
 
 	return keepAliveLoop(ctx, &c, printOnTick)
 }
 
+// printCPUSaturation warns once per server about data points
+// which were recorded while a core or hperf itself was saturated.
+func printCPUSaturation(dps []shared.DP) {
+	warned := make(map[string]struct{})
+	for i := range dps {
+		if !dps[i].CPUSaturated {
+			continue
+		}
+		if _, ok := warned[dps[i].Local]; ok {
+			continue
+		}
+		warned[dps[i].Local] = struct{}{}
+		fmt.Println(WarningStyle.Render(fmt.Sprintf(
+			"WARNING: CPU saturated on %s (%s), results may be CPU-bound rather than network-bound",
+			dps[i].Local, cpuSaturationDetail(&dps[i]),
+		)))
+	}
+}
+
+func cpuSaturationDetail(dp *shared.DP) string {
+	if dp.CPUCoreHighID < 0 {
+		return fmt.Sprintf("hperf at %d%%", dp.ProcessCPU)
+	}
+	return fmt.Sprintf("busiest core cpu%d at %d%%, hperf at %d%%", dp.CPUCoreHighID, dp.CPUCoreHigh, dp.ProcessCPU)
+}
+
 func ListTests(ctx context.Context, c shared.Config) (err error) {
 	cancelContext, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -446,6 +637,7 @@ func ListTests(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	skipIncompatibleHosts(shared.ListTests, &c)
 	itterateWebsockets(func(ws *wsClient) {
 		err = ws.Con.WriteJSON(ws.NewSignal(shared.ListTests, c))
 		if err != nil {
@@ -486,6 +678,65 @@ func ListTests(ctx context.Context, c shared.Config) (err error) {
 	return err
 }
 
+func Status(ctx context.Context, c shared.Config) (err error) {
+	cancelContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err = initializeClient(cancelContext, &c)
+	if err != nil {
+		return
+	}
+
+	skipIncompatibleHosts(shared.TestStatus, &c)
+	itterateWebsockets(func(ws *wsClient) {
+		err = ws.Con.WriteJSON(ws.NewSignal(shared.TestStatus, c))
+		if err != nil {
+			return
+		}
+	})
+
+	err = keepAliveLoop(ctx, &c, nil)
+	if err != nil {
+		return
+	}
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	printHeader(StatusHeaders)
+	for _, host := range c.Hosts {
+		reports := statusList[host]
+		slices.SortFunc(reports, func(a shared.TestStatusReport, b shared.TestStatusReport) int {
+			return a.Created.Compare(b.Created)
+		})
+		for _, r := range reports {
+			style := BaseStyle
+			switch r.State {
+			case shared.TestFailed:
+				style = ErrorStyle
+			case shared.TestStopped:
+				style = WarningStyle
+			}
+			PrintColumns(
+				style,
+				column{host, headerSlice[Host].width},
+				column{r.ID, headerSlice[ID].width},
+				column{r.State.String(), headerSlice[State].width},
+				column{formatTime(r.Started), headerSlice[Started].width},
+				column{formatTime(r.Ended), headerSlice[Ended].width},
+				column{r.Cause, headerSlice[Cause].width},
+			)
+		}
+	}
+
+	return nil
+}
+
+func parseTestStatus(host string, list []shared.TestStatusReport) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	statusList[host] = append(statusList[host], list...)
+}
+
 func DeleteTests(ctx context.Context, c shared.Config) (err error) {
 	cancelContext, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -494,6 +745,7 @@ func DeleteTests(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	skipIncompatibleHosts(shared.DeleteTests, &c)
 	itterateWebsockets(func(ws *wsClient) {
 		err = ws.Con.WriteJSON(ws.NewSignal(shared.DeleteTests, c))
 		if err != nil {
@@ -524,6 +776,8 @@ func DownloadTest(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
+	skipIncompatibleHosts(shared.GetTest, &c)
+	syncClocks(&c)
 	itterateWebsockets(func(ws *wsClient) {
 		err = ws.Con.WriteJSON(ws.NewSignal(shared.GetTest, c))
 		if err != nil {
@@ -555,12 +809,24 @@ func DownloadTest(ctx context.Context, c shared.Config) (err error) {
 		return err
 	}
 	defer f.Close()
+	for _, m := range mergeMetadata(responseMeta) {
+		_, err := shared.WriteStructAndNewLineToFile(f, shared.MetadataPoint, m)
+		if err != nil {
+			return err
+		}
+	}
 	for i := range responseDPS {
 		_, err := shared.WriteStructAndNewLineToFile(f, shared.DataPoint, responseDPS[i])
 		if err != nil {
 			return err
 		}
 	}
+	for i := range responseHosts {
+		_, err := shared.WriteStructAndNewLineToFile(f, shared.HostPoint, responseHosts[i])
+		if err != nil {
+			return err
+		}
+	}
 	for i := range responseERR {
 		_, err := shared.WriteStructAndNewLineToFile(f, shared.ErrorPoint, responseERR[i])
 		if err != nil {
@@ -571,10 +837,9 @@ func DownloadTest(ctx context.Context, c shared.Config) (err error) {
 	return nil
 }
 
-func AnalyzeBandwidthTest(ctx context.Context, c shared.Config) (err error) {
-	_, cancel := context.WithCancel(ctx)
-	defer cancel()
-
+// startAnalysis prints all data points and errors when asked to, it
+// returns false when there is nothing to analyze.
+func startAnalysis(c shared.Config) bool {
 	if c.PrintAll {
 		shared.INFO(" Printing all data points ..")
 		fmt.Println("")
@@ -594,40 +859,85 @@ func AnalyzeBandwidthTest(ctx context.Context, c shared.Config) (err error) {
 
 	if len(responseDPS) == 0 {
 		fmt.Println("No datapoints found")
+		return false
+	}
+
+	shared.INFO(" Analyzing data ..")
+	fmt.Println("")
+	return true
+}
+
+func AnalyzeBandwidthTest(ctx context.Context, c shared.Config) (err error) {
+	_, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !startAnalysis(c) {
 		return
 	}
+	analyzeBandwidthTest(responseDPS, c)
+	analyzeRamp(responseDPS, c.Ramp)
+	analyzeIntegrity(responseDPS, c.Verify)
 
+	analyzeSocket(responseDPS, c.Socket)
+	analyzeClock(responseDPS)
 	return nil
 }
 
-func AnalyzeLatencyTest(ctx context.Context, c shared.Config) (err error) {
+func AnalyzeUDPTest(ctx context.Context, c shared.Config) (err error) {
 	_, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	if c.PrintAll {
-		shared.INFO(" Printing all data points ..")
+	if !startAnalysis(c) {
+		return
+	}
+	analyzeUDPTest(responseDPS, c)
 
-		printSliceOfDataPoints(responseDPS, c)
+	analyzeClock(responseDPS)
+	return nil
+}
 
-		if len(responseERR) > 0 {
-			fmt.Println(" ____ ERRORS ____")
-		}
-		for i := range responseERR {
-			PrintTError(responseERR[i])
-		}
-		if len(responseERR) > 0 {
-			fmt.Println("")
-		}
+func AnalyzeMTUTest(ctx context.Context, c shared.Config) (err error) {
+	_, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !startAnalysis(c) {
+		return
 	}
-	if len(responseDPS) == 0 {
-		fmt.Println("No datapoints found")
+	analyzeMTUTest(responseDPS)
+
+	analyzeClock(responseDPS)
+	return nil
+}
+
+func AnalyzeChurnTest(ctx context.Context, c shared.Config) (err error) {
+	_, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !startAnalysis(c) {
 		return
 	}
+	analyzeChurnTest(responseDPS, c)
+	analyzeRate(responseDPS)
+	analyzeIntegrity(responseDPS, c.Verify)
 
-	shared.INFO(" Analyzing data ..")
-	fmt.Println("")
+	analyzeSocket(responseDPS, c.Socket)
+	analyzeClock(responseDPS)
+	return nil
+}
+
+func AnalyzeLatencyTest(ctx context.Context, c shared.Config) (err error) {
+	_, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !startAnalysis(c) {
+		return
+	}
 	analyzeLatencyTest(responseDPS, c)
+	analyzeRate(responseDPS)
+	analyzeIntegrity(responseDPS, c.Verify)
 
+	analyzeSocket(responseDPS, c.Socket)
+	analyzeClock(responseDPS)
 	return nil
 }
 
@@ -635,39 +945,18 @@ func AnalyzeTest(ctx context.Context, c shared.Config) (err error) {
 	_, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	f, err := os.Open(c.File)
+	meta, dps, hosts, errors, err := readTestFile(c.File)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	dps := make([]shared.DP, 0)
-	errors := make([]shared.TError, 0)
-
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		b := s.Bytes()
-		if bytes.HasPrefix(b[1:], shared.ErrorPoint.String()) {
-			dperr := new(shared.TError)
-			err := json.Unmarshal(b, dperr)
-			if err != nil {
-				return err
-			}
-			errors = append(errors, *dperr)
-		} else if bytes.HasPrefix(b, shared.DataPoint.String()) {
-			dp := new(shared.DP)
-			err := json.Unmarshal(b[1:], dp)
-			if err != nil {
-				return err
-			}
-			dps = append(dps, *dp)
-		} else {
-			shared.DEBUG(ErrorStyle.Render("Unknown data point encountered: ", string(b)))
-		}
-	}
+	printMetadata(meta)
 
 	if c.HostFilter != "" {
 		dps = shared.HostFilter(c.HostFilter, dps)
+		hosts = slices.DeleteFunc(hosts, func(h shared.HostDP) bool {
+			return !strings.Contains(h.Local, c.HostFilter)
+		})
 	}
 
 	if c.PrintStats {
@@ -691,17 +980,640 @@ func AnalyzeTest(ctx context.Context, c shared.Config) (err error) {
 		return
 	}
 
-	switch dps[0].Type {
+	testType := dps[0].Type
+	verify := false
+	var ramp []shared.RampStep
+	var socket shared.SocketOptions
+	if len(meta) > 0 {
+		testType = meta[0].Config.TestType
+		verify = meta[0].Config.Verify
+		ramp = meta[0].Config.Ramp
+		socket = meta[0].Config.Socket
+	}
+
+	switch testType {
 	case shared.RequestTest:
 		analyzeLatencyTest(dps, c)
-	case shared.StreamTest:
-		fmt.Println("")
-		fmt.Println("Detailed analysis for bandwidth testing is in development")
+	case shared.StreamTest, shared.TCPTest:
+		analyzeBandwidthTest(dps, c)
+	case shared.UDPTest:
+		analyzeUDPTest(dps, c)
+	case shared.ChurnTest:
+		analyzeChurnTest(dps, c)
+	case shared.MTUTest:
+		analyzeMTUTest(dps)
 	}
 
+	analyzeRamp(dps, ramp)
+	analyzeRate(dps)
+	analyzeIntegrity(dps, verify)
+	analyzeSocket(dps, socket)
+	analyzeClock(dps)
+	analyzePhases(dps)
+	analyzeInterfaces(hosts)
+	analyzeCPU(dps)
+
 	return nil
 }
 
+// analyzePhases prints where the time of HTTP requests is spent per link,
+// a slow first byte points at the peer while slow connects or writes
+// point at the network.
+func analyzePhases(dps []shared.DP) {
+	links := make(map[linkKey]*shared.RequestPhases)
+	keys := make([]linkKey, 0)
+	for i := range dps {
+		if dps[i].Phases == nil {
+			continue
+		}
+		k := newLinkKey(&dps[i])
+		p, ok := links[k]
+		if !ok {
+			p = new(shared.RequestPhases)
+			links[k] = p
+			keys = append(keys, k)
+		}
+		p.Add(*dps[i].Phases)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Request phases per link (averages) _____ ")
+	fmt.Println("")
+	printHeader(PhaseHeaders)
+	for _, k := range keys {
+		p := links[k]
+		PrintColumns(
+			BaseStyle,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{formatInt(p.DNS.Avg()), headerSlice[PhaseDNS].width},
+			column{formatInt(p.Connect.Avg()), headerSlice[PhaseConnect].width},
+			column{formatInt(p.TLS.Avg()), headerSlice[PhaseTLS].width},
+			column{formatInt(p.Write.Avg()), headerSlice[PhaseWrite].width},
+			column{formatInt(p.FirstByte.Avg()), headerSlice[PhaseFirstByte].width},
+			column{formatInt(p.FirstByte.High), headerSlice[PhaseFirstByteHigh].width},
+			column{formatUint(p.NewConns), headerSlice[NewConns].width},
+			column{formatUint(p.ReusedConns), headerSlice[ReusedConns].width},
+		)
+	}
+}
+
+type cpuStats struct {
+	count     int
+	user      int
+	system    int
+	softIRQ   int
+	ioWait    int
+	coreHigh  int
+	procHigh  int
+	rssHigh   uint64
+	saturated int
+	// the data point with the busiest core
+	worst *shared.DP
+}
+
+// analyzeCPU prints the CPU usage of every server and warns when
+// results were recorded while a core or hperf itself was saturated.
+func analyzeCPU(dps []shared.DP) {
+	servers := make(map[string]*cpuStats)
+	keys := make([]string, 0)
+	intervals := serverIntervals(dps)
+	for i := range intervals {
+		dp := &intervals[i]
+		if dp.ProcessRSS == 0 {
+			// older servers only report CPUUsedPercent
+			continue
+		}
+		local := hostOf(dp.Local)
+		s, ok := servers[local]
+		if !ok {
+			s = new(cpuStats)
+			servers[local] = s
+			keys = append(keys, local)
+		}
+		s.count++
+		s.user += dp.CPUUser
+		s.system += dp.CPUSystem
+		s.softIRQ += dp.CPUSoftIRQ
+		s.ioWait += dp.CPUIOWait
+		s.procHigh = max(s.procHigh, dp.ProcessCPU)
+		s.rssHigh = max(s.rssHigh, dp.ProcessRSS)
+		if s.worst == nil || dp.CPUCoreHigh > s.coreHigh {
+			s.coreHigh = dp.CPUCoreHigh
+			s.worst = dp
+		}
+		if dp.CPUSaturated {
+			s.saturated++
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	slices.Sort(keys)
+
+	fmt.Println("")
+	fmt.Println(" _____ CPU usage _____ ")
+	fmt.Println("")
+	printHeader(CPUHeaders)
+	for _, k := range keys {
+		s := servers[k]
+		style := BaseStyle
+		if s.saturated > 0 {
+			style = WarningStyle
+		}
+		n := s.count
+		PrintColumns(
+			style,
+			column{k, headerSlice[Local].width},
+			column{formatInt(int64(s.user / n)), headerSlice[CPUUserAvg].width},
+			column{formatInt(int64(s.system / n)), headerSlice[CPUSystemAvg].width},
+			column{formatInt(int64(s.softIRQ / n)), headerSlice[CPUSoftIRQAvg].width},
+			column{formatInt(int64(s.ioWait / n)), headerSlice[CPUIOWaitAvg].width},
+			column{formatInt(int64(s.coreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(s.procHigh)), headerSlice[ProcessCPUHigh].width},
+			column{shared.BToString(s.rssHigh), headerSlice[ProcessRSS].width},
+			column{formatInt(int64(s.saturated)), headerSlice[CPUSaturated].width},
+		)
+	}
+
+	for _, k := range keys {
+		s := servers[k]
+		if s.saturated == 0 {
+			continue
+		}
+		fmt.Println("")
+		fmt.Println(WarningStyle.Render(fmt.Sprintf(
+			"WARNING: CPU saturated on %s during %d of %d seconds (%s), results may be CPU-bound rather than network-bound",
+			k, s.saturated, s.count, cpuSaturationDetail(s.worst),
+		)))
+	}
+}
+
+// serverIntervals returns one data point for every server and interval, the
+// server stats are the same for all data points of an interval.
+func serverIntervals(dps []shared.DP) (list []shared.DP) {
+	type interval struct {
+		local   string
+		created time.Time
+	}
+	seen := make(map[interval]struct{})
+	for i := range dps {
+		iv := interval{local: dps[i].Local, created: dps[i].Created}
+		if _, ok := seen[iv]; ok {
+			continue
+		}
+		seen[iv] = struct{}{}
+		list = append(list, dps[i])
+	}
+	return
+}
+
+// analyzeInterfaces prints the interface counters of every server over the whole test
+func analyzeInterfaces(hosts []shared.HostDP) {
+	type nicKey struct {
+		local string
+		name  string
+	}
+	nics := make(map[nicKey]*shared.NICStats)
+	keys := make([]nicKey, 0)
+	for _, h := range hosts {
+		local := hostOf(h.Local)
+		for _, n := range h.NICs {
+			k := nicKey{local: local, name: n.Interface}
+			total, ok := nics[k]
+			if !ok {
+				total = &shared.NICStats{Interface: n.Interface}
+				nics[k] = total
+				keys = append(keys, k)
+			}
+			total.Add(n)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	slices.SortFunc(keys, func(a, b nicKey) int {
+		return strings.Compare(a.local+a.name, b.local+b.name)
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Network interfaces _____ ")
+	fmt.Println("")
+	printHeader(NICHeaders)
+	for _, k := range keys {
+		n := nics[k]
+		style := BaseStyle
+		if n.RXErrors+n.TXErrors+n.RXDropped+n.TXDropped+n.RXFifo+n.TXFifo+n.RXFrame > 0 {
+			style = WarningStyle
+		}
+		PrintColumns(
+			style,
+			column{k.local, headerSlice[Local].width},
+			column{n.Interface, headerSlice[Interface].width},
+			column{shared.BToString(n.RXBytes), headerSlice[NICRXBytes].width},
+			column{shared.BToString(n.TXBytes), headerSlice[NICTXBytes].width},
+			column{formatUint(n.RXErrors), headerSlice[NICRXErrors].width},
+			column{formatUint(n.TXErrors), headerSlice[NICTXErrors].width},
+			column{formatUint(n.RXDropped), headerSlice[NICRXDropped].width},
+			column{formatUint(n.TXDropped), headerSlice[NICTXDropped].width},
+			column{formatUint(n.RXFifo + n.TXFifo), headerSlice[NICFifo].width},
+			column{formatUint(n.RXFrame), headerSlice[NICFrame].width},
+		)
+	}
+}
+
+type rateStats struct {
+	target  float64
+	count   int
+	sum     float64
+	low     float64
+	tx      uint64
+	sendLag int64
+}
+
+// analyzeRate prints the target and achieved request rate of open-loop tests
+// per link. A link which falls behind its target could not keep up, the
+// latency of its requests includes the time they waited to be sent.
+func analyzeRate(dps []shared.DP) {
+	links := make(map[linkKey]*rateStats)
+	keys := make([]linkKey, 0)
+	for i := range dps {
+		if dps[i].TargetRate == 0 {
+			continue
+		}
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = &rateStats{low: math.MaxFloat64}
+			links[k] = l
+			keys = append(keys, k)
+		}
+		l.target = dps[i].TargetRate
+		l.count++
+		l.sum += dps[i].Rate
+		l.low = min(l.low, dps[i].Rate)
+		l.tx += dps[i].TX
+		l.sendLag = max(l.sendLag, dps[i].SendLag)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Request rate per link _____ ")
+	fmt.Println("")
+	printHeader(RateHeaders)
+	for _, k := range keys {
+		l := links[k]
+		avg := l.sum / float64(l.count)
+		style := BaseStyle
+		if avg < l.target*0.95 {
+			style = WarningStyle
+		}
+		PrintColumns(
+			style,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{formatRate(l.target), headerSlice[RateTarget].width},
+			column{formatRate(avg), headerSlice[RateAvg].width},
+			column{formatRate(l.low), headerSlice[RateLow].width},
+			column{shared.BWToString(l.tx / uint64(l.count)), headerSlice[TXAvg].width},
+			column{formatInt(l.sendLag), headerSlice[SendLag].width},
+		)
+	}
+}
+
+type integrityStats struct {
+	mismatches uint64
+	corrupted  uint64
+}
+
+// analyzeIntegrity prints the corrupted payloads per link, nothing is
+// printed when payloads were not verified and no corruption was found.
+func analyzeIntegrity(dps []shared.DP, verify bool) {
+	links := make(map[linkKey]*integrityStats)
+	keys := make([]linkKey, 0)
+	for i := range dps {
+		if dps[i].PayloadMismatches == 0 {
+			continue
+		}
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = new(integrityStats)
+			links[k] = l
+			keys = append(keys, k)
+		}
+		l.mismatches += dps[i].PayloadMismatches
+		l.corrupted += dps[i].CorruptedBytes
+	}
+
+	if len(keys) == 0 {
+		if verify {
+			fmt.Println("")
+			fmt.Println(SuccessStyle.Render(" Payload verification found no corrupted payloads "))
+		}
+		return
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Corrupted payloads per link _____ ")
+	fmt.Println("")
+	printHeader(IntegrityHeaders)
+	for _, k := range keys {
+		l := links[k]
+		PrintColumns(
+			ErrorStyle,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{formatUint(l.mismatches), headerSlice[PayloadMismatches].width},
+			column{formatUint(l.corrupted), headerSlice[CorruptedBytes].width},
+		)
+	}
+}
+
+type linkKey struct {
+	local     string
+	remote    string
+	direction shared.TestDirection
+}
+
+// newLinkKey returns the link of a data point, links are keyed
+// by the hosts of the servers without their ports.
+func newLinkKey(dp *shared.DP) linkKey {
+	return linkKey{
+		local:     hostOf(dp.Local),
+		remote:    hostOf(dp.Remote),
+		direction: shared.TestDirection(dp.Direction.String()),
+	}
+}
+
+// hostOf returns the host of an address, IPv6 addresses
+// keep their colons. Addresses without a port are returned as is.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+type linkStats struct {
+	count uint64
+	sum   uint64
+	high  uint64
+	low   uint64
+	rtt   tcpStats
+}
+
+// tcpStats sums the kernel TCP_INFO values of data points
+type tcpStats struct {
+	count   int64
+	rttSum  int64
+	rttHigh int64
+	retrans uint64
+}
+
+func (s *tcpStats) add(dp *shared.DP) {
+	s.retrans += dp.TCPRetransmits
+	if dp.TCPConnections == 0 {
+		return
+	}
+	s.count++
+	s.rttSum += dp.TCPRTT
+	s.rttHigh = max(s.rttHigh, dp.TCPRTT)
+}
+
+func (s *tcpStats) avgRTT() int64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.rttSum / s.count
+}
+
+// analyzeBandwidthTest prints the transfer rate per link and direction,
+// which makes asymmetric links stand out.
+func analyzeBandwidthTest(dps []shared.DP, _ shared.Config) {
+	links := make(map[linkKey]*linkStats)
+	keys := make([]linkKey, 0)
+	for i := range dps {
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = &linkStats{low: math.MaxUint64}
+			links[k] = l
+			keys = append(keys, k)
+		}
+		l.count++
+		l.sum += dps[i].TX
+		l.high = max(l.high, dps[i].TX)
+		l.low = min(l.low, dps[i].TX)
+		l.rtt.add(&dps[i])
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Bandwidth per link _____ ")
+	fmt.Println("")
+	printHeader(LinkHeaders)
+	for _, k := range keys {
+		l := links[k]
+		PrintColumns(
+			BaseStyle,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{shared.BWToString(l.sum / l.count), headerSlice[TXAvg].width},
+			column{shared.BWToString(l.high), headerSlice[TXH].width},
+			column{shared.BWToString(l.low), headerSlice[TXL].width},
+			column{formatInt(l.rtt.avgRTT()), headerSlice[TCPRTT].width},
+			column{formatUint(l.rtt.retrans), headerSlice[TCPRetransmits].width},
+		)
+	}
+}
+
+type udpPathStats struct {
+	sent      uint64
+	received  uint64
+	lost      uint64
+	reordered uint64
+	duplicate uint64
+	jitterH   int64
+	jitterL   int64
+}
+
+// analyzeUDPTest prints loss and jitter per path. Packets are counted by the
+// sender and loss is measured by the receiver, so both sides of a link are
+// joined to get the numbers for one direction.
+func analyzeUDPTest(dps []shared.DP, _ shared.Config) {
+	paths := make(map[linkKey]*udpPathStats)
+	keys := make([]linkKey, 0)
+	get := func(from string, to string) *udpPathStats {
+		k := linkKey{local: from, remote: to}
+		p, ok := paths[k]
+		if !ok {
+			p = &udpPathStats{jitterL: math.MaxInt64}
+			paths[k] = p
+			keys = append(keys, k)
+		}
+		return p
+	}
+
+	for i := range dps {
+		local := hostOf(dps[i].Local)
+		remote := hostOf(dps[i].Remote)
+
+		// TXCount is the total number of packets sent so far
+		sent := get(local, remote)
+		sent.sent = max(sent.sent, dps[i].TXCount)
+
+		received := get(remote, local)
+		received.received += dps[i].PacketsReceived
+		received.lost += dps[i].PacketsLost
+		received.reordered += dps[i].PacketsReordered
+		received.duplicate += dps[i].PacketsDuplicate
+		if dps[i].PacketsReceived > 0 {
+			received.jitterH = max(received.jitterH, dps[i].Jitter)
+			received.jitterL = min(received.jitterL, dps[i].Jitter)
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+" "+a.remote, b.local+" "+b.remote)
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Packet loss and jitter per path _____ ")
+	fmt.Println("")
+	printHeader(UDPLinkHeaders)
+	for _, k := range keys {
+		p := paths[k]
+		if p.jitterL == math.MaxInt64 {
+			p.jitterL = 0
+		}
+		PrintColumns(
+			BaseStyle,
+			column{k.local, headerSlice[From].width},
+			column{k.remote, headerSlice[To].width},
+			column{formatUint(p.sent), headerSlice[TXCount].width},
+			column{formatUint(p.received), headerSlice[PacketsReceived].width},
+			column{formatUint(p.lost), headerSlice[PacketsLost].width},
+			column{formatLoss(p.lost, p.received), headerSlice[LossPercent].width},
+			column{formatUint(p.reordered), headerSlice[PacketsReordered].width},
+			column{formatUint(p.duplicate), headerSlice[PacketsDuplicate].width},
+			column{formatInt(p.jitterH), headerSlice[JitterHigh].width},
+			column{formatInt(p.jitterL), headerSlice[JitterLow].width},
+		)
+	}
+}
+
+type churnStats struct {
+	count     uint64
+	rateSum   uint64
+	rateHigh  uint64
+	conns     uint64
+	connect   shared.PhaseTiming
+	exhausted uint64
+	timeouts  uint64
+	refused   uint64
+	reset     uint64
+}
+
+// analyzeChurnTest prints the connection rate and failures per link
+// and the connect latency percentiles over all connections.
+func analyzeChurnTest(dps []shared.DP, c shared.Config) {
+	links := make(map[linkKey]*churnStats)
+	keys := make([]linkKey, 0)
+	histograms := make([]shared.EncodedHistogram, 0, len(dps))
+	for i := range dps {
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = new(churnStats)
+			links[k] = l
+			keys = append(keys, k)
+		}
+		l.count++
+		l.rateSum += dps[i].ConnsPerSecond
+		l.rateHigh = max(l.rateHigh, dps[i].ConnsPerSecond)
+		l.conns += dps[i].Phases.NewConnCount()
+		if dps[i].Phases != nil {
+			l.connect.Add(dps[i].Phases.Connect)
+		}
+		l.exhausted += dps[i].ConnPortExhausted
+		l.timeouts += dps[i].ConnTimeouts
+		l.refused += dps[i].ConnRefused
+		l.reset += dps[i].ConnReset
+		histograms = append(histograms, dps[i].ConnectHistogram)
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ New connections per link _____ ")
+	fmt.Println("")
+	printHeader(ChurnLinkHeaders)
+	for _, k := range keys {
+		l := links[k]
+		style := BaseStyle
+		if l.exhausted+l.timeouts+l.refused+l.reset > 0 {
+			style = WarningStyle
+		}
+		PrintColumns(
+			style,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{formatUint(l.rateSum / l.count), headerSlice[ConnsPerSecondAvg].width},
+			column{formatUint(l.rateHigh), headerSlice[ConnsPerSecondHigh].width},
+			column{formatUint(l.conns), headerSlice[NewConns].width},
+			column{formatInt(l.connect.Avg()), headerSlice[PhaseConnect].width},
+			column{formatInt(l.connect.High), headerSlice[ConnectHigh].width},
+			column{formatUint(l.exhausted), headerSlice[ConnPortExhausted].width},
+			column{formatUint(l.timeouts), headerSlice[ConnTimeouts].width},
+			column{formatUint(l.refused), headerSlice[ConnRefused].width},
+			column{formatUint(l.reset), headerSlice[ConnReset].width},
+		)
+	}
+
+	connect := shared.MergeHistograms(histograms...)
+	if connect.Count() == 0 {
+		return
+	}
+	fmt.Println("")
+	if c.Micro {
+		fmt.Println(" Connect latency percentiles, time: Microseconds")
+	} else {
+		fmt.Println(" Connect latency percentiles, time: Milliseconds")
+	}
+	fmt.Println("")
+	PrintHistogramPercentiles(WarningStyle, "TCP", connect, c)
+}
+
 func analyzeLatencyTest(dps []shared.DP, c shared.Config) {
 	shared.SortDataPoints(dps, c)
 
@@ -757,14 +1669,73 @@ func analyzeLatencyTest(dps []shared.DP, c shared.Config) {
 		fmt.Println(" Time: Milliseconds")
 	}
 	fmt.Println("")
-	PrintPercentiles(SuccessStyle, "P10", dps10stats, c)
-	PrintPercentiles(WarningStyle, "P50", dps50stats, c)
-	PrintPercentiles(ErrorStyle, "P90", dps90stats, c)
-	PrintPercentiles(ErrorStyle, "P99", dps99stats, c)
+
+	printTCPInfoSummary(dps, dps99s)
+
+	rms, ttfb := mergeLatencyHistograms(dps)
+	if rms.Count() == 0 && ttfb.Count() == 0 {
+		// files from older servers only contain the per-second high and low
+		PrintPercentiles(SuccessStyle, "P10", dps10stats, c)
+		PrintPercentiles(WarningStyle, "P50", dps50stats, c)
+		PrintPercentiles(ErrorStyle, "P90", dps90stats, c)
+		PrintPercentiles(ErrorStyle, "P99", dps99stats, c)
+		return
+	}
+
+	fmt.Println(" Percentiles over all requests")
+	fmt.Println("")
+	PrintHistogramPercentiles(WarningStyle, "RMS", rms, c)
+	PrintHistogramPercentiles(WarningStyle, "TTFB", ttfb, c)
+
+	byDirection := make(map[shared.TestDirection][]shared.DP)
+	for i := range dps {
+		d := shared.TestDirection(dps[i].Direction.String())
+		byDirection[d] = append(byDirection[d], dps[i])
+	}
+	if len(byDirection) < 2 {
+		return
+	}
+	for _, d := range shared.DirectionBoth.Directions() {
+		rms, ttfb := mergeLatencyHistograms(byDirection[d])
+		fmt.Println("")
+		fmt.Println(" Direction:", d)
+		fmt.Println("")
+		PrintHistogramPercentiles(WarningStyle, "RMS", rms, c)
+		PrintHistogramPercentiles(WarningStyle, "TTFB", ttfb, c)
+	}
+}
+
+// printTCPInfoSummary compares the kernel TCP state of the slowest data points
+// to the whole test, retransmits or a higher RTT in the P99 data points point
+// at the network rather than the servers.
+func printTCPInfoSummary(dps []shared.DP, dps99s []shared.DP) {
+	var all, p99 tcpStats
+	for i := range dps {
+		all.add(&dps[i])
+	}
+	for i := range dps99s {
+		p99.add(&dps99s[i])
+	}
+	if all.count == 0 {
+		return
+	}
+	fmt.Printf(" TCP: RTT(avg) %dus RTT(high) %dus Retransmits %d\n", all.avgRTT(), all.rttHigh, all.retrans)
+	fmt.Printf(" TCP P99 data points: RTT(avg) %dus RTT(high) %dus Retransmits %d\n", p99.avgRTT(), p99.rttHigh, p99.retrans)
+	fmt.Println("")
+}
+
+func mergeLatencyHistograms(dps []shared.DP) (rms *shared.Histogram, ttfb *shared.Histogram) {
+	rmsList := make([]shared.EncodedHistogram, 0, len(dps))
+	ttfbList := make([]shared.EncodedHistogram, 0, len(dps))
+	for i := range dps {
+		rmsList = append(rmsList, dps[i].RMSHistogram)
+		ttfbList = append(ttfbList, dps[i].TTFBHistogram)
+	}
+	return shared.MergeHistograms(rmsList...), shared.MergeHistograms(ttfbList...)
 }
 
 func MakeCSV(ctx context.Context, c shared.Config) (err error) {
-	byteValue, err := os.ReadFile(c.File)
+	meta, dps, _, _, err := readTestFile(c.File)
 	if err != nil {
 		return err
 	}
@@ -775,39 +1746,45 @@ func MakeCSV(ctx context.Context, c shared.Config) (err error) {
 	}
 	defer file.Close()
 
-	fb := bytes.NewBuffer(byteValue)
-	scanner := bufio.NewScanner(fb)
-
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
-	if err := writer.Write(getStructFields(new(shared.DP))); err != nil {
+	header := append(getStructFields(new(shared.DP)), metadataCSVFields...)
+	if err := writer.Write(header); err != nil {
 		return err
 	}
 
-	for scanner.Scan() {
-		b := scanner.Bytes()
-		if bytes.HasPrefix(b, shared.DataPoint.String()) {
-			dp := new(shared.DP)
-			err = json.Unmarshal(b[1:], dp)
-			if err != nil {
-				return err
-			}
-
-			if err := writer.Write(dpToSlice(dp)); err != nil {
-				return err
-			}
+	for i := range dps {
+		row := append(dpToSlice(&dps[i]), metadataToCSV(metadataForServer(meta, dps[i].Local))...)
+		if err := writer.Write(row); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+var metadataCSVFields = []string{"HperfVersion", "Hostname", "Concurrency", "PayloadSize", "BufferSize", "RequestDelay"}
+
+func metadataToCSV(m *shared.TestMetadata) []string {
+	if m == nil {
+		return make([]string, len(metadataCSVFields))
+	}
+	return []string{
+		m.HperfVersion,
+		m.Hostname,
+		strconv.Itoa(m.Config.Concurrency),
+		strconv.Itoa(m.Config.PayloadSize),
+		strconv.Itoa(m.Config.BufferSize),
+		strconv.Itoa(m.Config.RequestDelay),
+	}
+}
+
 // Function to get field names of the struct
 func getStructFields(s interface{}) []string {
 	t := reflect.TypeOf(s).Elem()
 	fields := make([]string, t.NumField())
 	for i := 0; i < t.NumField(); i++ {
-		fields[i] = t.Field(i).Tag.Get("json")
+		fields[i], _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
 		if fields[i] == "" {
 			fields[i] = t.Field(i).Name
 		}
@@ -849,6 +1826,10 @@ func printSliceOfDataPoints(dps []shared.DP, c shared.Config) {
 			printDataPointHeaders(data[0].Type)
 		}
 		dp := data[i]
+		if dp.CPUSaturated {
+			printTableRow(WarningStyle, &dp, dp.Type)
+			continue
+		}
 		printTableRow(BaseStyle, &dp, dp.Type)
 	}
 }
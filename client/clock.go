@@ -0,0 +1,200 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	clockSyncSamples = 8
+	clockSyncTimeout = 2 * time.Second
+	// offsets below this are not worth a warning
+	clockWarnOffset = 10 * time.Millisecond
+)
+
+// syncClocks estimates how far the clock of every server is ahead of the
+// client, timestamps received from the servers are corrected by it so
+// results of different servers can be merged into one timeline.
+func syncClocks(c *shared.Config) {
+	wg := sync.WaitGroup{}
+	itterateWebsockets(func(ws *wsClient) {
+		if ws.Info == nil || !slices.Contains(ws.Info.Signals, shared.ClockSync) {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws.syncClock(c)
+		}()
+	})
+	wg.Wait()
+
+	itterateWebsockets(func(ws *wsClient) {
+		offset := ws.ClockOffset()
+		if offset.Abs() >= clockWarnOffset {
+			fmt.Println(WarningStyle.Render(fmt.Sprintf("Clock of %s is %s ahead of this client, timestamps are corrected", ws.Host, offset)))
+		}
+	})
+}
+
+func (ws *wsClient) syncClock(c *shared.Config) {
+	var e shared.ClockEstimator
+	for range clockSyncSamples {
+		msg := ws.NewSignal(shared.ClockSync, *c)
+		msg.Clock = &shared.ClockSample{T1: time.Now().UnixNano()}
+		err := ws.Con.WriteJSON(msg)
+		if err != nil {
+			PrintError(err)
+			return
+		}
+		select {
+		case s := <-ws.clock:
+			e.Add(s)
+		case <-time.After(clockSyncTimeout):
+		}
+	}
+	m, ok := e.Estimate()
+	if ok {
+		ws.clockOffset.Store(m.At(time.Now().UnixNano()))
+	}
+}
+
+// ClockOffset is how far the server clock is ahead of the client
+func (ws *wsClient) ClockOffset() time.Duration {
+	return time.Duration(ws.clockOffset.Load())
+}
+
+// receiveClockSample hands the answer of a clock exchange to syncClock,
+// received is when it was read from the websocket.
+func (ws *wsClient) receiveClockSample(s *shared.ClockSample, received time.Time) {
+	if s == nil {
+		return
+	}
+	s.T4 = received.UnixNano()
+	select {
+	case ws.clock <- *s:
+	default:
+	}
+}
+
+func correctDataPoints(dps []shared.DP, offset time.Duration) {
+	for i := range dps {
+		dps[i].Created = dps[i].Created.Add(-offset)
+	}
+}
+
+func correctHosts(hosts []shared.HostDP, offset time.Duration) {
+	for i := range hosts {
+		hosts[i].Created = hosts[i].Created.Add(-offset)
+	}
+}
+
+func correctErrors(errs []shared.TError, offset time.Duration) {
+	for i := range errs {
+		errs[i].Created = errs[i].Created.Add(-offset)
+	}
+}
+
+func correctMetadata(m *shared.TestMetadata, offset time.Duration) {
+	for _, t := range []*time.Time{&m.Created, &m.Started, &m.Ended} {
+		if !t.IsZero() {
+			*t = t.Add(-offset)
+		}
+	}
+	m.ClockOffset = offset.Microseconds()
+}
+
+type clockStats struct {
+	samples uint64
+	offset  int64
+	drift   float64
+	rtt     int64
+	out     int64
+	outHigh int64
+	back    int64
+	// data points with one-way delays
+	count int64
+}
+
+// analyzeClock prints the clock offset between servers and the RTT of the
+// clock probes, split into the delay in each direction using the offset.
+func analyzeClock(dps []shared.DP) {
+	links := make(map[[2]string]*clockStats)
+	keys := make([][2]string, 0)
+	for i := range dps {
+		if dps[i].ClockSamples == 0 {
+			continue
+		}
+		k := [2]string{hostOf(dps[i].Local), hostOf(dps[i].Remote)}
+		l, ok := links[k]
+		if !ok {
+			l = new(clockStats)
+			links[k] = l
+			keys = append(keys, k)
+		}
+		// the estimate gets better over time, keep the last one
+		l.samples += dps[i].ClockSamples
+		l.offset = dps[i].ClockOffset
+		l.drift = dps[i].ClockDrift
+		l.count++
+		l.rtt += dps[i].ClockRTT
+		l.out += dps[i].OneWayOut
+		l.back += dps[i].OneWayBack
+		l.outHigh = max(l.outHigh, dps[i].OneWayOut)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+a[1], b[0]+b[1])
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Clock offset and probe delay _____ ")
+	fmt.Println("")
+	printHeader(ClockHeaders)
+	for _, k := range keys {
+		l := links[k]
+		out := l.out / l.count
+		back := l.back / l.count
+		PrintColumns(
+			BaseStyle,
+			column{k[0], headerSlice[Local].width},
+			column{k[1], headerSlice[Remote].width},
+			column{formatInt(l.offset), headerSlice[ClockOffset].width},
+			column{fmt.Sprintf("%.2f", l.drift), headerSlice[ClockDrift].width},
+			column{formatInt(l.rtt / l.count), headerSlice[ClockRTT].width},
+			column{formatInt(out), headerSlice[OneWayOut].width},
+			column{formatInt(back), headerSlice[OneWayBack].width},
+			column{formatInt(out - back), headerSlice[OneWayAsym].width},
+			column{formatUint(l.samples), headerSlice[ClockSamples].width},
+		)
+	}
+	fmt.Println("")
+	fmt.Println(" Offset is how far the clock of the remote server is ahead of the local one. RTT is")
+	fmt.Println(" measured, * values are derived: they split the RTT using the offset, which assumes the")
+	fmt.Println(" fastest probes took the same time in both directions. They show which direction slowed")
+	fmt.Println(" down compared to those probes, not an independent one-way measurement.")
+}
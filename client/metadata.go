@@ -0,0 +1,198 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+var responseMeta = make([]shared.TestMetadata, 0)
+
+// readTestFile parses a file created by 'download' or
+// copied directly from the server storage path.
+func readTestFile(path string) (meta []shared.TestMetadata, dps []shared.DP, hosts []shared.HostDP, errs []shared.TError, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	defer f.Close()
+
+	meta = make([]shared.TestMetadata, 0)
+	dps = make([]shared.DP, 0)
+	hosts = make([]shared.HostDP, 0)
+	errs = make([]shared.TError, 0)
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for s.Scan() {
+		b := s.Bytes()
+		if len(b) == 0 {
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(b, shared.ErrorPoint.String()):
+			dperr := new(shared.TError)
+			err = json.Unmarshal(b[1:], dperr)
+			if err != nil {
+				return
+			}
+			errs = append(errs, *dperr)
+		case bytes.HasPrefix(b, shared.DataPoint.String()):
+			dp := new(shared.DP)
+			err = json.Unmarshal(b[1:], dp)
+			if err != nil {
+				return
+			}
+			dps = append(dps, *dp)
+		case bytes.HasPrefix(b, shared.HostPoint.String()):
+			h := new(shared.HostDP)
+			err = json.Unmarshal(b[1:], h)
+			if err != nil {
+				return
+			}
+			hosts = append(hosts, *h)
+		case bytes.HasPrefix(b, shared.MetadataPoint.String()):
+			m := new(shared.TestMetadata)
+			err = json.Unmarshal(b[1:], m)
+			if err != nil {
+				return
+			}
+			meta = append(meta, *m)
+		default:
+			shared.DEBUG(ErrorStyle.Render("Unknown data point encountered: ", string(b)))
+		}
+	}
+
+	return mergeMetadata(meta), dps, hosts, errs, s.Err()
+}
+
+// mergeMetadata keeps the most complete metadata record per server
+func mergeMetadata(list []shared.TestMetadata) (merged []shared.TestMetadata) {
+	byServer := make(map[string]shared.TestMetadata)
+	for _, m := range list {
+		old, ok := byServer[m.Server]
+		if !ok || m.Ended.After(old.Ended) || (m.Ended.Equal(old.Ended) && m.Started.After(old.Started)) {
+			byServer[m.Server] = m
+		}
+	}
+
+	merged = make([]shared.TestMetadata, 0, len(byServer))
+	for _, m := range byServer {
+		merged = append(merged, m)
+	}
+	slices.SortFunc(merged, func(a, b shared.TestMetadata) int {
+		if a.Server < b.Server {
+			return -1
+		} else if a.Server > b.Server {
+			return 1
+		}
+		return 0
+	})
+	return
+}
+
+func metadataForServer(meta []shared.TestMetadata, server string) *shared.TestMetadata {
+	for i := range meta {
+		if meta[i].Server == server {
+			return &meta[i]
+		}
+	}
+	return nil
+}
+
+func testTypeToString(t shared.TestType) string {
+	switch t {
+	case shared.RequestTest:
+		return "latency"
+	case shared.StreamTest:
+		return "bandwidth"
+	case shared.UDPTest:
+		return "udp"
+	case shared.TCPTest:
+		return "tcp"
+	case shared.ChurnTest:
+		return "churn"
+	case shared.MTUTest:
+		return "mtu"
+	default:
+		return "unknown(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+func printMetadata(meta []shared.TestMetadata) {
+	if len(meta) == 0 {
+		return
+	}
+	c := meta[0].Config
+	fmt.Println("")
+	fmt.Println(" Test ID:", meta[0].TestID)
+	fmt.Println(" Type:", testTypeToString(c.TestType))
+	if c.Sweep != "" {
+		fmt.Println(" Sweep:", c.Sweep)
+	}
+	fmt.Println(" Duration:", c.Duration, "seconds")
+	fmt.Println(" Concurrency:", c.Concurrency)
+	fmt.Println(" Payload size:", shared.BToString(uint64(c.PayloadSize)))
+	fmt.Println(" Payload content:", c.PayloadContent)
+	fmt.Println(" Buffer size:", shared.BToString(uint64(c.BufferSize)))
+	fmt.Println(" Request delay:", c.RequestDelay, "ms")
+	if c.Socket.IsSet() {
+		fmt.Println(" Socket options:", c.Socket.String())
+	}
+	fmt.Println("")
+
+	printHeader(MetadataHeaders)
+	for _, m := range meta {
+		style := BaseStyle
+		if m.Config.Concurrency != c.Concurrency ||
+			m.Config.PayloadSize != c.PayloadSize ||
+			m.Config.BufferSize != c.BufferSize ||
+			m.Config.PayloadContent.String() != c.PayloadContent.String() ||
+			m.Config.Socket != c.Socket ||
+			m.Config.TestType != c.TestType {
+			// servers running with different settings skew the results
+			style = WarningStyle
+		}
+		PrintColumns(
+			style,
+			column{m.Server, headerSlice[Host].width},
+			column{m.Hostname, headerSlice[Hostname].width},
+			column{m.HperfVersion, headerSlice[Version].width},
+			column{formatTime(m.Started), headerSlice[Started].width},
+			column{formatTime(m.Ended), headerSlice[Ended].width},
+			column{formatInt(m.ClockOffset), headerSlice[ClockOffset].width},
+		)
+	}
+	fmt.Println("")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02/01/2006 15:04:05")
+}
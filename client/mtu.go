@@ -0,0 +1,169 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/minio/hperf/shared"
+)
+
+type mtuPath struct {
+	from string
+	to   string
+	low  int
+	high int
+	// last values reported by the sender
+	interfaceMTU int
+	kernelMTU    int
+}
+
+// problems returns what is wrong with the path, nil when the
+// largest packets of the sending interface get through.
+func (p *mtuPath) problems(reverse *mtuPath) (list []string) {
+	switch {
+	case p.high == 0:
+		return []string{"unreachable"}
+	case p.interfaceMTU > 0 && p.low < p.interfaceMTU:
+		list = append(list, "below interface MTU")
+		if p.kernelMTU > p.low {
+			// nothing told the kernel that large packets are dropped
+			list = append(list, "no ICMP")
+		}
+	}
+	if p.low != p.high {
+		list = append(list, "unstable")
+	}
+	if reverse != nil && reverse.low != p.low {
+		list = append(list, "asymmetric")
+	}
+	return list
+}
+
+// analyzeMTUTest prints the path MTU between all servers as a matrix with
+// the senders as rows, followed by the paths which are asymmetric, below
+// the MTU of the sending interface or changed during the test.
+func analyzeMTUTest(dps []shared.DP) {
+	paths := make(map[[2]string]*mtuPath)
+	hosts := make([]string, 0)
+	for i := range dps {
+		if dps[i].Type != shared.MTUTest {
+			continue
+		}
+		from := hostOf(dps[i].Local)
+		to := hostOf(dps[i].Remote)
+		p, ok := paths[[2]string{from, to}]
+		if !ok {
+			p = &mtuPath{from: from, to: to, low: math.MaxInt}
+			paths[[2]string{from, to}] = p
+		}
+		p.low = min(p.low, dps[i].PathMTU)
+		p.high = max(p.high, dps[i].PathMTU)
+		p.interfaceMTU = dps[i].InterfaceMTU
+		p.kernelMTU = dps[i].KernelMTU
+		for _, h := range []string{from, to} {
+			if !slices.Contains(hosts, h) {
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	slices.Sort(hosts)
+
+	fmt.Println("")
+	fmt.Println(" _____ Path MTU (rows send to columns) _____ ")
+	fmt.Println("")
+	if headerSlice[0].width == 0 {
+		initHeaders()
+	}
+	matrixHeader := []column{{"From \\ To", headerSlice[From].width}}
+	for _, h := range hosts {
+		matrixHeader = append(matrixHeader, column{h, headerSlice[To].width})
+	}
+	PrintColumns(HeaderStyle, matrixHeader...)
+	for _, from := range hosts {
+		style := BaseStyle
+		row := []column{{from, headerSlice[From].width}}
+		for _, to := range hosts {
+			value := ""
+			p, ok := paths[[2]string{from, to}]
+			switch {
+			case from == to:
+				value = "-"
+			case !ok:
+				value = "?"
+			default:
+				value = formatInt(int64(p.low))
+				if len(p.problems(paths[[2]string{to, from}])) > 0 {
+					value += " !"
+					style = WarningStyle
+				}
+			}
+			row = append(row, column{value, headerSlice[To].width})
+		}
+		PrintColumns(style, row...)
+	}
+
+	keys := make([][2]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+a[1], b[0]+b[1])
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ MTU per path _____ ")
+	fmt.Println("")
+	printHeader(MTULinkHeaders)
+	for _, k := range keys {
+		p := paths[k]
+		reverse := paths[[2]string{k[1], k[0]}]
+		back := "?"
+		if reverse != nil {
+			back = formatInt(int64(reverse.low))
+		}
+
+		style := BaseStyle
+		result := "ok"
+		problems := p.problems(reverse)
+		if len(problems) > 0 {
+			style = WarningStyle
+			result = strings.Join(problems, ", ")
+		}
+		if p.high == 0 {
+			style = ErrorStyle
+		}
+		PrintColumns(
+			style,
+			column{p.from, headerSlice[From].width},
+			column{p.to, headerSlice[To].width},
+			column{formatInt(int64(p.low)), headerSlice[PathMTULow].width},
+			column{formatInt(int64(p.high)), headerSlice[PathMTUHigh].width},
+			column{back, headerSlice[ReverseMTU].width},
+			column{formatInt(int64(p.interfaceMTU)), headerSlice[InterfaceMTU].width},
+			column{formatInt(int64(p.kernelMTU)), headerSlice[KernelMTU].width},
+			column{result, headerSlice[MTUResult].width},
+		)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	// a step has to add this much throughput to count as growing
+	rampGrowth = 1.05
+	// a step is degraded when the RTT more than doubles compared
+	// to the first step and grows by at least a millisecond.
+	rampRTTFactor   = 2
+	rampRTTIncrease = 1000
+)
+
+type rampStepStats struct {
+	step     shared.RampStep
+	index    int
+	count    uint64
+	txSum    uint64
+	rtt      tcpStats
+	errCount int
+	// new errors compared to the previous steps
+	errors int
+}
+
+func (s *rampStepStats) tx() uint64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.txSum / s.count
+}
+
+type rampLink struct {
+	steps []*rampStepStats
+	best  int
+	knee  int
+	// why the knee ends the ramp
+	reason string
+}
+
+// findKnee walks the steps in order and stops at the first step which
+// does not add throughput, or where the RTT jumps or new errors appear.
+// The best step is the one before the knee.
+func (l *rampLink) findKnee() {
+	l.best = 0
+	l.knee = -1
+	baseRTT := l.steps[0].rtt.avgRTT()
+	for i := 1; i < len(l.steps); i++ {
+		s := l.steps[i]
+		if s.count == 0 {
+			// the test ended before this step
+			break
+		}
+		rtt := s.rtt.avgRTT()
+		switch {
+		case s.errors > 0:
+			l.reason = "errors"
+		case baseRTT > 0 && rtt > baseRTT*rampRTTFactor && rtt-baseRTT >= rampRTTIncrease:
+			l.reason = "latency"
+		case float64(s.tx()) <= float64(l.steps[l.best].tx())*rampGrowth:
+			l.reason = "throughput"
+		default:
+			l.best = i
+			continue
+		}
+		l.knee = i
+		return
+	}
+	l.reason = "still growing"
+}
+
+// analyzeRamp prints the throughput of every ramp step per link and the best
+// setting before throughput stopped growing or latency and errors jumped.
+func analyzeRamp(dps []shared.DP, ramp []shared.RampStep) {
+	if len(ramp) == 0 {
+		return
+	}
+
+	links := make(map[linkKey]*rampLink)
+	keys := make([]linkKey, 0)
+	for i := range dps {
+		if dps[i].RampStep >= len(ramp) {
+			continue
+		}
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = new(rampLink)
+			for si := range ramp {
+				l.steps = append(l.steps, &rampStepStats{step: ramp[si], index: si})
+			}
+			links[k] = l
+			keys = append(keys, k)
+		}
+
+		// ErrCount is the number of errors on the server so far
+		s := l.steps[dps[i].RampStep]
+		s.count++
+		s.txSum += dps[i].TX
+		s.rtt.add(&dps[i])
+		s.errCount = max(s.errCount, dps[i].ErrCount)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	slices.SortFunc(keys, func(a, b linkKey) int {
+		return strings.Compare(a.local+a.remote+string(a.direction), b.local+b.remote+string(b.direction))
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Throughput per ramp step _____ ")
+	fmt.Println("")
+	printHeader(RampHeaders)
+	for _, k := range keys {
+		l := links[k]
+		prev := 0
+		for _, s := range l.steps {
+			s.errors = max(s.errCount-prev, 0)
+			prev = max(prev, s.errCount)
+		}
+		l.findKnee()
+
+		for _, s := range l.steps {
+			style := BaseStyle
+			result := ""
+			switch s.index {
+			case l.best:
+				style = SuccessStyle
+				result = "best"
+			case l.knee:
+				style = WarningStyle
+				result = "knee (" + l.reason + ")"
+			}
+			PrintColumns(
+				style,
+				column{k.local, headerSlice[Local].width},
+				column{k.remote, headerSlice[Remote].width},
+				column{k.direction.String(), headerSlice[Direction].width},
+				column{formatInt(int64(s.index + 1)), headerSlice[RampStep].width},
+				column{formatInt(int64(s.step.Concurrency)), headerSlice[Concurrency].width},
+				column{shared.BToString(uint64(s.step.PayloadSize)), headerSlice[PayloadSize].width},
+				column{shared.BWToString(s.tx()), headerSlice[TXAvg].width},
+				column{formatInt(s.rtt.avgRTT()), headerSlice[TCPRTT].width},
+				column{formatUint(s.rtt.retrans), headerSlice[TCPRetransmits].width},
+				column{formatInt(int64(s.errors)), headerSlice[ErrCount].width},
+				column{result, headerSlice[RampResult].width},
+			)
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println(" _____ Best setting per link _____ ")
+	fmt.Println("")
+	printHeader(RampBestHeaders)
+	for _, k := range keys {
+		l := links[k]
+		best := l.steps[l.best]
+		knee := ""
+		if l.knee >= 0 {
+			knee = l.steps[l.knee].step.String()
+		}
+		PrintColumns(
+			BaseStyle,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.direction.String(), headerSlice[Direction].width},
+			column{formatInt(int64(best.step.Concurrency)), headerSlice[Concurrency].width},
+			column{shared.BToString(uint64(best.step.PayloadSize)), headerSlice[PayloadSize].width},
+			column{shared.BWToString(best.tx()), headerSlice[TXAvg].width},
+			column{knee, headerSlice[RampKnee].width},
+			column{l.reason, headerSlice[RampResult].width},
+		)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/minio/hperf/shared"
+)
+
+type socketKey struct {
+	local  string
+	remote string
+	// dial for connections from local to remote,
+	// accept for connections local accepted from remote.
+	side string
+}
+
+// analyzeSocket prints the socket options the kernel applied on both
+// ends of every link and marks the options which did not stick.
+func analyzeSocket(dps []shared.DP, o shared.SocketOptions) {
+	if !o.IsSet() {
+		return
+	}
+
+	sockets := make(map[socketKey]*shared.SocketState)
+	keys := make([]socketKey, 0)
+	add := func(k socketKey, s *shared.SocketState) {
+		if s == nil {
+			return
+		}
+		if _, ok := sockets[k]; !ok {
+			keys = append(keys, k)
+		}
+		// keep the last state, connections can be re-opened
+		sockets[k] = s
+	}
+	for i := range dps {
+		local := hostOf(dps[i].Local)
+		remote := hostOf(dps[i].Remote)
+		add(socketKey{local: local, remote: remote, side: "dial"}, dps[i].Socket)
+		add(socketKey{local: local, remote: remote, side: "accept"}, dps[i].AcceptedSocket)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	slices.SortFunc(keys, func(a, b socketKey) int {
+		return strings.Compare(a.local+a.remote+a.side, b.local+b.remote+b.side)
+	})
+
+	fmt.Println("")
+	fmt.Println(" _____ Socket options _____ ")
+	fmt.Println("")
+	fmt.Println(" Requested:", o.String())
+	fmt.Println("")
+	printHeader(SocketHeaders)
+	for _, k := range keys {
+		s := sockets[k]
+		style := SuccessStyle
+		result := "applied"
+		missing := o.NotApplied(*s)
+		late := o.AfterHandshake()
+		if len(missing) > 0 {
+			style = WarningStyle
+			result = "not applied: " + strings.Join(missing, ", ")
+		} else if k.side == "accept" && len(late) > 0 {
+			result = "late: " + strings.Join(late, ", ")
+		}
+		PrintColumns(
+			style,
+			column{k.local, headerSlice[Local].width},
+			column{k.remote, headerSlice[Remote].width},
+			column{k.side, headerSlice[SocketSide].width},
+			column{shared.BToString(uint64(s.SendBuffer)), headerSlice[SendBuffer].width},
+			column{shared.BToString(uint64(s.ReceiveBuffer)), headerSlice[ReceiveBuffer].width},
+			column{s.Congestion, headerSlice[Congestion].width},
+			column{formatInt(int64(s.MaxSegment)), headerSlice[MaxSegment].width},
+			column{fmt.Sprint(s.NoDelay), headerSlice[NoDelay].width},
+			column{shared.FormatTOS(s.TOS), headerSlice[TOS].width},
+			column{result, headerSlice[SocketResult].width},
+		)
+	}
+	fmt.Println("")
+	fmt.Println(" The kernel reports twice the requested buffer sizes and the current segment size,")
+	fmt.Println(" buffers are capped by net.core.wmem_max and net.core.rmem_max.")
+	if len(o.AfterHandshake()) > 0 {
+		fmt.Println(" Accepted connections only get the receive buffer and segment size after the handshake,")
+		fmt.Println(" the segment size and window scale were negotiated by then, rows marked late show")
+		fmt.Println(" values which did not shape the transfer.")
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/minio/hperf/shared"
+)
+
+type sweepResult struct {
+	id    string
+	point shared.SweepPoint
+	err   error
+	// sum of the average throughput of every link
+	tx        uint64
+	rmsHigh   int64
+	rtt       tcpStats
+	errCount  int
+	saturated int
+}
+
+// RunSweep runs one test per combination of the sweep, one after the
+// other, and prints the combinations ranked by throughput. Every test
+// is saved on the servers under its own ID so the raw results can be
+// downloaded per combination.
+func RunSweep(ctx context.Context, c shared.Config, points []shared.SweepPoint) (err error) {
+	parent := c.TestID
+	results := make([]*sweepResult, 0, len(points))
+	for i, p := range points {
+		if ctx.Err() != nil {
+			break
+		}
+
+		tc := c
+		tc.TestID = shared.SweepTestID(parent, i)
+		tc.Sweep = parent
+		tc.Concurrency = p.Concurrency
+		tc.PayloadSize = p.PayloadSize
+		tc.BufferSize = p.BufferSize
+
+		fmt.Println("")
+		shared.INFO(fmt.Sprintf(" Sweep test %d/%d (%s): %s", i+1, len(points), tc.TestID, p))
+		fmt.Println("")
+
+		responseLock.Lock()
+		responseDPS = make([]shared.DP, 0)
+		responseERR = make([]shared.TError, 0)
+		responseHosts = make([]shared.HostDP, 0)
+		responseLock.Unlock()
+
+		r := &sweepResult{id: tc.TestID, point: p}
+		r.err = RunTest(ctx, tc)
+		if r.err != nil {
+			PrintError(r.err)
+		}
+		itterateWebsockets(func(ws *wsClient) {
+			ws.Close()
+		})
+
+		responseLock.Lock()
+		r.summarize(responseDPS, len(responseERR), tc)
+		responseLock.Unlock()
+		results = append(results, r)
+	}
+
+	printSweepSummary(results, c)
+	return ctx.Err()
+}
+
+func (r *sweepResult) summarize(dps []shared.DP, errCount int, c shared.Config) {
+	links := make(map[linkKey]*linkStats)
+	for i := range dps {
+		if dps[i].TestID != r.id {
+			continue
+		}
+		k := newLinkKey(&dps[i])
+		l, ok := links[k]
+		if !ok {
+			l = new(linkStats)
+			links[k] = l
+		}
+		l.count++
+		l.sum += dps[i].TX
+		r.rtt.add(&dps[i])
+		r.rmsHigh = max(r.rmsHigh, dps[i].RMSH)
+		if dps[i].CPUSaturated {
+			r.saturated++
+		}
+	}
+	for _, l := range links {
+		r.tx += l.sum / l.count
+	}
+	if !c.Micro {
+		r.rmsHigh = r.rmsHigh / 1000
+	}
+	r.errCount = errCount
+	if r.err == nil && len(links) == 0 {
+		r.err = fmt.Errorf("no data points received")
+	}
+}
+
+// printSweepSummary ranks the combinations by throughput, combinations
+// which failed are listed last.
+func printSweepSummary(results []*sweepResult, c shared.Config) {
+	if len(results) == 0 {
+		return
+	}
+
+	ranked := slices.Clone(results)
+	slices.SortStableFunc(ranked, func(a, b *sweepResult) int {
+		switch {
+		case (a.err == nil) != (b.err == nil):
+			if a.err == nil {
+				return -1
+			}
+			return 1
+		case a.tx > b.tx:
+			return -1
+		case a.tx < b.tx:
+			return 1
+		}
+		return a.errCount - b.errCount
+	})
+
+	headers := SweepHeaders
+	if c.TestType == shared.RequestTest {
+		headers = SweepLatencyHeaders
+	}
+
+	fmt.Println("")
+	fmt.Println(" _____ Sweep " + c.TestID + " ranked by throughput _____ ")
+	fmt.Println("")
+	printHeader(headers)
+	for i, r := range ranked {
+		style := BaseStyle
+		rank := formatInt(int64(i + 1))
+		switch {
+		case r.err != nil:
+			style = ErrorStyle
+			rank = "-"
+		case r.errCount > 0 || r.saturated > 0:
+			style = WarningStyle
+		case i == 0:
+			style = SuccessStyle
+		}
+		columns := []column{
+			{rank, headerSlice[IntNumber].width},
+			{r.id, headerSlice[ID].width},
+			{formatInt(int64(r.point.Concurrency)), headerSlice[Concurrency].width},
+			{shared.BToString(uint64(r.point.PayloadSize)), headerSlice[PayloadSize].width},
+			{shared.BToString(uint64(r.point.BufferSize)), headerSlice[BufferSize].width},
+			{shared.BWToString(r.tx), headerSlice[TXAggregate].width},
+		}
+		if c.TestType == shared.RequestTest {
+			columns = append(columns, column{formatInt(r.rmsHigh), headerSlice[RMSH].width})
+		}
+		columns = append(columns,
+			column{formatInt(r.rtt.avgRTT()), headerSlice[TCPRTT].width},
+			column{formatUint(r.rtt.retrans), headerSlice[TCPRetransmits].width},
+			column{formatInt(int64(r.errCount)), headerSlice[ErrCount].width},
+			column{formatInt(int64(r.saturated)), headerSlice[CPUSaturated].width},
+		)
+		PrintColumns(style, columns...)
+	}
+
+	fmt.Println("")
+	fmt.Println(" Combinations with errors or saturated CPUs are marked, the raw results of every")
+	fmt.Println(" combination are saved on the servers and can be downloaded by ID:")
+	fmt.Println("  hperf download --hosts " + strings.Join(c.Hosts, ",") + " --id " + ranked[0].id + " --file " + ranked[0].id + ".json")
+	fmt.Println("")
+}
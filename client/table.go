@@ -20,7 +20,6 @@ package client
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -65,6 +64,99 @@ const (
 	CPULow
 	ID
 	HumanTime
+	Host
+	State
+	Started
+	Ended
+	Cause
+	Hostname
+	Version
+	Direction
+	TXAvg
+	PacketsReceived
+	PacketsLost
+	LossPercent
+	PacketsReordered
+	PacketsDuplicate
+	Jitter
+	JitterHigh
+	JitterLow
+	From
+	To
+	TCPRTT
+	TCPRTTHigh
+	TCPRetransmits
+	TCPCwnd
+	Interface
+	NICRXBytes
+	NICTXBytes
+	NICRXErrors
+	NICTXErrors
+	NICRXDropped
+	NICTXDropped
+	NICFifo
+	NICFrame
+	CPUCoreHigh
+	ProcessCPU
+	ProcessCPUHigh
+	ProcessRSS
+	CPUSaturated
+	CPUUserAvg
+	CPUSystemAvg
+	CPUSoftIRQAvg
+	CPUIOWaitAvg
+	PhaseDNS
+	PhaseConnect
+	PhaseTLS
+	PhaseWrite
+	PhaseFirstByte
+	PhaseFirstByteHigh
+	NewConns
+	ReusedConns
+	ConnsPerSecond
+	ConnsPerSecondHigh
+	ConnsPerSecondLow
+	ConnsPerSecondAvg
+	ConnectHigh
+	ConnPortExhausted
+	ConnTimeouts
+	ConnRefused
+	ConnReset
+	PayloadMismatches
+	CorruptedBytes
+	RateTarget
+	RateAvg
+	RateLow
+	SendLag
+	RampStep
+	Concurrency
+	PayloadSize
+	RampKnee
+	RampResult
+	BufferSize
+	TXAggregate
+	PathMTU
+	PathMTULow
+	PathMTUHigh
+	ReverseMTU
+	InterfaceMTU
+	KernelMTU
+	MTUResult
+	ClockOffset
+	ClockDrift
+	ClockRTT
+	OneWayOut
+	OneWayBack
+	OneWayAsym
+	ClockSamples
+	SocketSide
+	SendBuffer
+	ReceiveBuffer
+	Congestion
+	MaxSegment
+	NoDelay
+	TOS
+	SocketResult
 	header_length
 )
 
@@ -92,6 +184,99 @@ func initHeaders() {
 	headerSlice[CPULow] = header{"CPU(low)", 9}
 	headerSlice[ID] = header{"ID", 30}
 	headerSlice[HumanTime] = header{"Time", 30}
+	headerSlice[Host] = header{"Host", 20}
+	headerSlice[State] = header{"State", 10}
+	headerSlice[Started] = header{"Started", 20}
+	headerSlice[Ended] = header{"Ended", 20}
+	headerSlice[Cause] = header{"Cause", 30}
+	headerSlice[Hostname] = header{"Hostname", 20}
+	headerSlice[Version] = header{"Version", 20}
+	headerSlice[Direction] = header{"Dir", 8}
+	headerSlice[TXAvg] = header{"TX(avg)", 10}
+	headerSlice[PacketsReceived] = header{"#RX", 10}
+	headerSlice[PacketsLost] = header{"#Lost", 8}
+	headerSlice[LossPercent] = header{"Loss(%)", 8}
+	headerSlice[PacketsReordered] = header{"#Reorder", 8}
+	headerSlice[PacketsDuplicate] = header{"#Dup", 6}
+	headerSlice[Jitter] = header{"Jitter(us)", 10}
+	headerSlice[JitterHigh] = header{"JitH(us)", 10}
+	headerSlice[JitterLow] = header{"JitL(us)", 10}
+	headerSlice[From] = header{"From", 15}
+	headerSlice[To] = header{"To", 15}
+	headerSlice[TCPRTT] = header{"RTT(us)", 9}
+	headerSlice[TCPRTTHigh] = header{"RTTH(us)", 9}
+	headerSlice[TCPRetransmits] = header{"#Retrans", 9}
+	headerSlice[TCPCwnd] = header{"Cwnd", 6}
+	headerSlice[Interface] = header{"Interface", 12}
+	headerSlice[NICRXBytes] = header{"RX(total)", 12}
+	headerSlice[NICTXBytes] = header{"TX(total)", 12}
+	headerSlice[NICRXErrors] = header{"#RXErr", 8}
+	headerSlice[NICTXErrors] = header{"#TXErr", 8}
+	headerSlice[NICRXDropped] = header{"#RXDrop", 8}
+	headerSlice[NICTXDropped] = header{"#TXDrop", 8}
+	headerSlice[NICFifo] = header{"#Fifo", 8}
+	headerSlice[NICFrame] = header{"#Frame", 8}
+	headerSlice[CPUCoreHigh] = header{"Core(high)", 10}
+	headerSlice[ProcessCPU] = header{"hperf(cpu)", 10}
+	headerSlice[ProcessCPUHigh] = header{"hperf(high)", 11}
+	headerSlice[ProcessRSS] = header{"hperf(rss)", 10}
+	headerSlice[CPUSaturated] = header{"#Saturated", 10}
+	headerSlice[CPUUserAvg] = header{"Usr(avg)", 9}
+	headerSlice[CPUSystemAvg] = header{"Sys(avg)", 9}
+	headerSlice[CPUSoftIRQAvg] = header{"SIRQ(avg)", 9}
+	headerSlice[CPUIOWaitAvg] = header{"IOW(avg)", 9}
+	headerSlice[PhaseDNS] = header{"DNS(us)", 9}
+	headerSlice[PhaseConnect] = header{"Conn(us)", 9}
+	headerSlice[PhaseTLS] = header{"TLS(us)", 9}
+	headerSlice[PhaseWrite] = header{"Write(us)", 10}
+	headerSlice[PhaseFirstByte] = header{"1stB(us)", 10}
+	headerSlice[PhaseFirstByteHigh] = header{"1stBH(us)", 10}
+	headerSlice[NewConns] = header{"#NewConn", 9}
+	headerSlice[ReusedConns] = header{"#Reused", 9}
+	headerSlice[ConnsPerSecond] = header{"Conn/s", 8}
+	headerSlice[ConnsPerSecondHigh] = header{"Conn/s(high)", 12}
+	headerSlice[ConnsPerSecondLow] = header{"Conn/s(low)", 12}
+	headerSlice[ConnsPerSecondAvg] = header{"Conn/s(avg)", 12}
+	headerSlice[ConnectHigh] = header{"ConnH(us)", 10}
+	headerSlice[ConnPortExhausted] = header{"#PortExh", 9}
+	headerSlice[ConnTimeouts] = header{"#Timeout", 9}
+	headerSlice[ConnRefused] = header{"#Refused", 9}
+	headerSlice[ConnReset] = header{"#Reset", 7}
+	headerSlice[PayloadMismatches] = header{"#Corrupt", 9}
+	headerSlice[CorruptedBytes] = header{"Corrupt(B)", 11}
+	headerSlice[RateTarget] = header{"Target/s", 10}
+	headerSlice[RateAvg] = header{"Rate(avg)", 10}
+	headerSlice[RateLow] = header{"Rate(low)", 10}
+	headerSlice[SendLag] = header{"Lag(us)", 10}
+	headerSlice[RampStep] = header{"Step", 5}
+	headerSlice[Concurrency] = header{"Conc", 6}
+	headerSlice[PayloadSize] = header{"Payload", 10}
+	headerSlice[RampKnee] = header{"Knee", 16}
+	headerSlice[RampResult] = header{"Result", 20}
+	headerSlice[BufferSize] = header{"Buffer", 10}
+	headerSlice[TXAggregate] = header{"TX(links)", 12}
+	headerSlice[PathMTU] = header{"MTU(path)", 10}
+	headerSlice[PathMTULow] = header{"MTU(low)", 9}
+	headerSlice[PathMTUHigh] = header{"MTU(high)", 10}
+	headerSlice[ReverseMTU] = header{"MTU(back)", 10}
+	headerSlice[InterfaceMTU] = header{"MTU(iface)", 11}
+	headerSlice[KernelMTU] = header{"MTU(kernel)", 12}
+	headerSlice[MTUResult] = header{"Result", 30}
+	headerSlice[ClockOffset] = header{"Offset(us)", 11}
+	headerSlice[ClockDrift] = header{"Drift(ppm)", 11}
+	headerSlice[ClockRTT] = header{"RTT(us)", 9}
+	headerSlice[OneWayOut] = header{"Out*(us)", 9}
+	headerSlice[OneWayBack] = header{"Back*(us)", 10}
+	headerSlice[OneWayAsym] = header{"Asym*(us)", 10}
+	headerSlice[ClockSamples] = header{"#Probes", 9}
+	headerSlice[SocketSide] = header{"Side", 8}
+	headerSlice[SendBuffer] = header{"SndBuf", 11}
+	headerSlice[ReceiveBuffer] = header{"RcvBuf", 11}
+	headerSlice[Congestion] = header{"Congestion", 11}
+	headerSlice[MaxSegment] = header{"MSS", 7}
+	headerSlice[NoDelay] = header{"NoDelay", 8}
+	headerSlice[TOS] = header{"TOS", 16}
+	headerSlice[SocketResult] = header{"Result", 30}
 }
 
 func GenerateFormatString(columnCount int) (fs string) {
@@ -103,12 +288,35 @@ func GenerateFormatString(columnCount int) (fs string) {
 
 var (
 	ListHeaders          = []HeaderField{IntNumber, ID, HumanTime}
-	BandwidthHeaders     = []HeaderField{Created, Local, Remote, TX, ErrCount, DroppedPackets, MemoryUsage, CPUUsage}
-	LatencyHeaders       = []HeaderField{Created, Local, Remote, RMSH, RMSL, TTFBH, TTFBL, TX, TXCount, ErrCount, DroppedPackets, MemoryUsage, CPUUsage}
-	FullDataPointHeaders = []HeaderField{Created, Local, Remote, RMSH, RMSL, TTFBH, TTFBL, TX, TXCount, ErrCount, DroppedPackets, MemoryUsage, CPUUsage}
-
-	RealTimeBandwidthHeaders = []HeaderField{ErrCount, TXCount, TXH, TXL, TXT, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow}
-	RealTimeLatencyHeaders   = []HeaderField{ErrCount, TXCount, TXH, TXL, TXT, RMSH, RMSL, TTFBH, TTFBL, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow}
+	StatusHeaders        = []HeaderField{Host, ID, State, Started, Ended, Cause}
+	MetadataHeaders      = []HeaderField{Host, Hostname, Version, Started, Ended, ClockOffset}
+	LinkHeaders          = []HeaderField{Local, Remote, Direction, TXAvg, TXH, TXL, TCPRTT, TCPRetransmits}
+	NICHeaders           = []HeaderField{Local, Interface, NICRXBytes, NICTXBytes, NICRXErrors, NICTXErrors, NICRXDropped, NICTXDropped, NICFifo, NICFrame}
+	CPUHeaders           = []HeaderField{Local, CPUUserAvg, CPUSystemAvg, CPUSoftIRQAvg, CPUIOWaitAvg, CPUCoreHigh, ProcessCPUHigh, ProcessRSS, CPUSaturated}
+	PhaseHeaders         = []HeaderField{Local, Remote, Direction, PhaseDNS, PhaseConnect, PhaseTLS, PhaseWrite, PhaseFirstByte, PhaseFirstByteHigh, NewConns, ReusedConns}
+	ChurnLinkHeaders     = []HeaderField{Local, Remote, Direction, ConnsPerSecondAvg, ConnsPerSecondHigh, NewConns, PhaseConnect, ConnectHigh, ConnPortExhausted, ConnTimeouts, ConnRefused, ConnReset}
+	ChurnHeaders         = []HeaderField{Created, Local, Remote, Direction, ConnsPerSecond, PhaseConnect, ConnectHigh, ConnPortExhausted, ConnTimeouts, ConnRefused, ConnReset, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+	IntegrityHeaders     = []HeaderField{Local, Remote, Direction, PayloadMismatches, CorruptedBytes}
+	RampHeaders          = []HeaderField{Local, Remote, Direction, RampStep, Concurrency, PayloadSize, TXAvg, TCPRTT, TCPRetransmits, ErrCount, RampResult}
+	RampBestHeaders      = []HeaderField{Local, Remote, Direction, Concurrency, PayloadSize, TXAvg, RampKnee, RampResult}
+	SweepHeaders         = []HeaderField{IntNumber, ID, Concurrency, PayloadSize, BufferSize, TXAggregate, TCPRTT, TCPRetransmits, ErrCount, CPUSaturated}
+	SweepLatencyHeaders  = []HeaderField{IntNumber, ID, Concurrency, PayloadSize, BufferSize, TXAggregate, RMSH, TCPRTT, TCPRetransmits, ErrCount, CPUSaturated}
+	ClockHeaders         = []HeaderField{Local, Remote, ClockOffset, ClockDrift, ClockRTT, OneWayOut, OneWayBack, OneWayAsym, ClockSamples}
+	SocketHeaders        = []HeaderField{Local, Remote, SocketSide, SendBuffer, ReceiveBuffer, Congestion, MaxSegment, NoDelay, TOS, SocketResult}
+	RateHeaders          = []HeaderField{Local, Remote, Direction, RateTarget, RateAvg, RateLow, TXAvg, SendLag}
+	MTULinkHeaders       = []HeaderField{From, To, PathMTULow, PathMTUHigh, ReverseMTU, InterfaceMTU, KernelMTU, MTUResult}
+	MTUHeaders           = []HeaderField{Created, Local, Remote, TXCount, PathMTU, InterfaceMTU, KernelMTU, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+	UDPLinkHeaders       = []HeaderField{From, To, TXCount, PacketsReceived, PacketsLost, LossPercent, PacketsReordered, PacketsDuplicate, JitterHigh, JitterLow}
+	UDPHeaders           = []HeaderField{Created, Local, Remote, TX, TXCount, PacketsReceived, PacketsLost, LossPercent, PacketsReordered, PacketsDuplicate, Jitter, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+	BandwidthHeaders     = []HeaderField{Created, Local, Remote, Direction, TX, TCPRTT, TCPRetransmits, TCPCwnd, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+	LatencyHeaders       = []HeaderField{Created, Local, Remote, Direction, RMSH, RMSL, TTFBH, TTFBL, PhaseFirstByte, NewConns, TX, TXCount, TCPRTT, TCPRetransmits, TCPCwnd, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+	FullDataPointHeaders = []HeaderField{Created, Local, Remote, Direction, RMSH, RMSL, TTFBH, TTFBL, PhaseFirstByte, NewConns, TX, TXCount, TCPRTT, TCPRetransmits, TCPCwnd, ErrCount, DroppedPackets, MemoryUsage, CPUUsage, CPUCoreHigh, ProcessCPU}
+
+	RealTimeBandwidthHeaders = []HeaderField{ErrCount, TXCount, TXH, TXL, TXT, TCPRTTHigh, TCPRetransmits, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow, CPUCoreHigh, ProcessCPUHigh}
+	RealTimeUDPHeaders       = []HeaderField{ErrCount, TXCount, TXH, TXL, TXT, PacketsReceived, PacketsLost, LossPercent, PacketsReordered, PacketsDuplicate, JitterHigh, JitterLow, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow, CPUCoreHigh, ProcessCPUHigh}
+	RealTimeMTUHeaders       = []HeaderField{ErrCount, TXCount, PathMTULow, PathMTUHigh, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow, CPUCoreHigh, ProcessCPUHigh}
+	RealTimeChurnHeaders     = []HeaderField{ErrCount, TXCount, ConnsPerSecondHigh, ConnsPerSecondLow, ConnectHigh, ConnPortExhausted, ConnTimeouts, ConnRefused, ConnReset, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow, CPUCoreHigh, ProcessCPUHigh}
+	RealTimeLatencyHeaders   = []HeaderField{ErrCount, TXCount, TXH, TXL, TXT, RMSH, RMSL, TTFBH, TTFBL, TCPRTTHigh, TCPRetransmits, DroppedPackets, MemoryHigh, MemoryLow, CPUHigh, CPULow, CPUCoreHigh, ProcessCPUHigh}
 )
 
 var (
@@ -178,6 +386,46 @@ func PrintPercentiles(style lipgloss.Style, tag string, dps []int64, c shared.Co
 	))
 }
 
+func PrintHistogramPercentilesHeader(style lipgloss.Style, tag string) {
+	fs := GenerateFormatString(8)
+	hs := []interface{}{
+		6, tag,
+		10, "count",
+		10, "min",
+		10, "P50",
+		10, "P90",
+		10, "P99",
+		10, "P99.9",
+		10, "max",
+	}
+	fmt.Println(style.Render(
+		fmt.Sprintf(fs, hs...),
+	))
+}
+
+func PrintHistogramPercentiles(style lipgloss.Style, tag string, h *shared.Histogram, c shared.Config) {
+	PrintHistogramPercentilesHeader(style, tag)
+	values := []int64{
+		h.Min(),
+		h.Percentile(50),
+		h.Percentile(90),
+		h.Percentile(99),
+		h.Percentile(99.9),
+		h.Max(),
+	}
+	columns := []column{
+		{"", 6},
+		{formatUint(h.Count()), 10},
+	}
+	for _, v := range values {
+		if !c.Micro {
+			v = v / 1000
+		}
+		columns = append(columns, column{formatInt(v), 10})
+	}
+	PrintColumns(BaseStyle, columns...)
+}
+
 func PrintColumns(style lipgloss.Style, columns ...column) {
 	fs := GenerateFormatString(len(columns))
 	hs := make([]interface{}, 0)
@@ -191,10 +439,16 @@ func PrintColumns(style lipgloss.Style, columns ...column) {
 
 func printDataPointHeaders(t shared.TestType) {
 	switch t {
-	case shared.StreamTest:
+	case shared.StreamTest, shared.TCPTest:
 		printHeader(BandwidthHeaders)
 	case shared.RequestTest:
 		printHeader(LatencyHeaders)
+	case shared.UDPTest:
+		printHeader(UDPHeaders)
+	case shared.ChurnTest:
+		printHeader(ChurnHeaders)
+	case shared.MTUTest:
+		printHeader(MTUHeaders)
 	default:
 		printHeader(FullDataPointHeaders)
 	}
@@ -202,17 +456,23 @@ func printDataPointHeaders(t shared.TestType) {
 
 func printRealTimeHeaders(t shared.TestType) {
 	switch t {
-	case shared.StreamTest:
+	case shared.StreamTest, shared.TCPTest:
 		printHeader(RealTimeBandwidthHeaders)
 	case shared.RequestTest:
 		printHeader(RealTimeLatencyHeaders)
+	case shared.UDPTest:
+		printHeader(RealTimeUDPHeaders)
+	case shared.ChurnTest:
+		printHeader(RealTimeChurnHeaders)
+	case shared.MTUTest:
+		printHeader(RealTimeMTUHeaders)
 	default:
 	}
 }
 
 func printRealTimeRow(style lipgloss.Style, entry *shared.TestOutput, t shared.TestType) {
 	switch t {
-	case shared.StreamTest:
+	case shared.StreamTest, shared.TCPTest:
 		PrintColumns(
 			style,
 			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
@@ -220,11 +480,15 @@ func printRealTimeRow(style lipgloss.Style, entry *shared.TestOutput, t shared.T
 			column{shared.BWToString(entry.TXH), headerSlice[TXH].width},
 			column{shared.BWToString(entry.TXL), headerSlice[TXL].width},
 			column{shared.BToString(entry.TXT), headerSlice[TXT].width},
+			column{formatInt(entry.RTTH), headerSlice[TCPRTTHigh].width},
+			column{formatUint(entry.RTC), headerSlice[TCPRetransmits].width},
 			column{formatInt(int64(entry.DP)), headerSlice[DroppedPackets].width},
 			column{formatInt(int64(entry.MH)), headerSlice[MemoryHigh].width},
 			column{formatInt(int64(entry.ML)), headerSlice[MemoryLow].width},
 			column{formatInt(int64(entry.CH)), headerSlice[CPUHigh].width},
 			column{formatInt(int64(entry.CL)), headerSlice[CPULow].width},
+			column{formatInt(int64(entry.CCH)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.PCH)), headerSlice[ProcessCPUHigh].width},
 		)
 		return
 	case shared.RequestTest:
@@ -239,11 +503,73 @@ func printRealTimeRow(style lipgloss.Style, entry *shared.TestOutput, t shared.T
 			column{formatInt(entry.RMSL), headerSlice[RMSL].width},
 			column{formatInt(entry.TTFBH), headerSlice[TTFBH].width},
 			column{formatInt(entry.TTFBL), headerSlice[TTFBL].width},
+			column{formatInt(entry.RTTH), headerSlice[TCPRTTHigh].width},
+			column{formatUint(entry.RTC), headerSlice[TCPRetransmits].width},
+			column{formatInt(int64(entry.DP)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MH)), headerSlice[MemoryHigh].width},
+			column{formatInt(int64(entry.ML)), headerSlice[MemoryLow].width},
+			column{formatInt(int64(entry.CH)), headerSlice[CPUHigh].width},
+			column{formatInt(int64(entry.CL)), headerSlice[CPULow].width},
+			column{formatInt(int64(entry.CCH)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.PCH)), headerSlice[ProcessCPUHigh].width},
+		)
+	case shared.UDPTest:
+		PrintColumns(
+			style,
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatUint(entry.TXC), headerSlice[TXCount].width},
+			column{shared.BWToString(entry.TXH), headerSlice[TXH].width},
+			column{shared.BWToString(entry.TXL), headerSlice[TXL].width},
+			column{shared.BToString(entry.TXT), headerSlice[TXT].width},
+			column{formatUint(entry.RXC), headerSlice[PacketsReceived].width},
+			column{formatUint(entry.LC), headerSlice[PacketsLost].width},
+			column{formatLoss(entry.LC, entry.RXC), headerSlice[LossPercent].width},
+			column{formatUint(entry.ROC), headerSlice[PacketsReordered].width},
+			column{formatUint(entry.DUPC), headerSlice[PacketsDuplicate].width},
+			column{formatInt(entry.JH), headerSlice[JitterHigh].width},
+			column{formatInt(entry.JL), headerSlice[JitterLow].width},
+			column{formatInt(int64(entry.DP)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MH)), headerSlice[MemoryHigh].width},
+			column{formatInt(int64(entry.ML)), headerSlice[MemoryLow].width},
+			column{formatInt(int64(entry.CH)), headerSlice[CPUHigh].width},
+			column{formatInt(int64(entry.CL)), headerSlice[CPULow].width},
+			column{formatInt(int64(entry.CCH)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.PCH)), headerSlice[ProcessCPUHigh].width},
+		)
+	case shared.ChurnTest:
+		PrintColumns(
+			style,
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatUint(entry.TXC), headerSlice[TXCount].width},
+			column{formatUint(entry.CPSH), headerSlice[ConnsPerSecondHigh].width},
+			column{formatUint(entry.CPSL), headerSlice[ConnsPerSecondLow].width},
+			column{formatInt(entry.CTH), headerSlice[ConnectHigh].width},
+			column{formatUint(entry.PEC), headerSlice[ConnPortExhausted].width},
+			column{formatUint(entry.TOC), headerSlice[ConnTimeouts].width},
+			column{formatUint(entry.RFC), headerSlice[ConnRefused].width},
+			column{formatUint(entry.RSC), headerSlice[ConnReset].width},
+			column{formatInt(int64(entry.DP)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MH)), headerSlice[MemoryHigh].width},
+			column{formatInt(int64(entry.ML)), headerSlice[MemoryLow].width},
+			column{formatInt(int64(entry.CH)), headerSlice[CPUHigh].width},
+			column{formatInt(int64(entry.CL)), headerSlice[CPULow].width},
+			column{formatInt(int64(entry.CCH)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.PCH)), headerSlice[ProcessCPUHigh].width},
+		)
+	case shared.MTUTest:
+		PrintColumns(
+			style,
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatUint(entry.TXC), headerSlice[TXCount].width},
+			column{formatInt(int64(entry.MTUL)), headerSlice[PathMTULow].width},
+			column{formatInt(int64(entry.MTUH)), headerSlice[PathMTUHigh].width},
 			column{formatInt(int64(entry.DP)), headerSlice[DroppedPackets].width},
 			column{formatInt(int64(entry.MH)), headerSlice[MemoryHigh].width},
 			column{formatInt(int64(entry.ML)), headerSlice[MemoryLow].width},
 			column{formatInt(int64(entry.CH)), headerSlice[CPUHigh].width},
 			column{formatInt(int64(entry.CL)), headerSlice[CPULow].width},
+			column{formatInt(int64(entry.CCH)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.PCH)), headerSlice[ProcessCPUHigh].width},
 		)
 	default:
 		shared.DEBUG("Unknown test type, not printing table")
@@ -252,50 +578,127 @@ func printRealTimeRow(style lipgloss.Style, entry *shared.TestOutput, t shared.T
 
 func printTableRow(style lipgloss.Style, entry *shared.DP, t shared.TestType) {
 	switch t {
-	case shared.StreamTest:
+	case shared.StreamTest, shared.TCPTest:
 		PrintColumns(
 			style,
 			column{entry.Created.Format("15:04:05"), headerSlice[Created].width},
-			column{strings.Split(entry.Local, ":")[0], headerSlice[Local].width},
-			column{strings.Split(entry.Remote, ":")[0], headerSlice[Remote].width},
+			column{hostOf(entry.Local), headerSlice[Local].width},
+			column{hostOf(entry.Remote), headerSlice[Remote].width},
+			column{entry.Direction.String(), headerSlice[Direction].width},
 			column{shared.BWToString(entry.TX), headerSlice[TX].width},
+			column{formatInt(entry.TCPRTT), headerSlice[TCPRTT].width},
+			column{formatUint(entry.TCPRetransmits), headerSlice[TCPRetransmits].width},
+			column{formatUint(entry.TCPCwnd), headerSlice[TCPCwnd].width},
 			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
 			column{formatInt(int64(entry.DroppedPackets)), headerSlice[DroppedPackets].width},
 			column{formatInt(int64(entry.MemoryUsedPercent)), headerSlice[MemoryUsage].width},
 			column{formatInt(int64(entry.CPUUsedPercent)), headerSlice[CPUUsage].width},
+			column{formatInt(int64(entry.CPUCoreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.ProcessCPU)), headerSlice[ProcessCPU].width},
 		)
 		return
 	case shared.RequestTest:
 		PrintColumns(
 			style,
 			column{entry.Created.Format("15:04:05"), headerSlice[Created].width},
-			column{strings.Split(entry.Local, ":")[0], headerSlice[Local].width},
-			column{strings.Split(entry.Remote, ":")[0], headerSlice[Remote].width},
+			column{hostOf(entry.Local), headerSlice[Local].width},
+			column{hostOf(entry.Remote), headerSlice[Remote].width},
+			column{entry.Direction.String(), headerSlice[Direction].width},
 			column{formatInt(entry.RMSH), headerSlice[RMSH].width},
 			column{formatInt(entry.RMSL), headerSlice[RMSL].width},
 			column{formatInt(entry.TTFBH), headerSlice[TTFBH].width},
 			column{formatInt(entry.TTFBL), headerSlice[TTFBH].width},
+			column{formatInt(entry.Phases.FirstByteAvg()), headerSlice[PhaseFirstByte].width},
+			column{formatUint(entry.Phases.NewConnCount()), headerSlice[NewConns].width},
 			column{shared.BWToString(entry.TX), headerSlice[TX].width},
 			column{formatUint(entry.TXCount), headerSlice[TXCount].width},
+			column{formatInt(entry.TCPRTT), headerSlice[TCPRTT].width},
+			column{formatUint(entry.TCPRetransmits), headerSlice[TCPRetransmits].width},
+			column{formatUint(entry.TCPCwnd), headerSlice[TCPCwnd].width},
 			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
 			column{formatInt(int64(entry.DroppedPackets)), headerSlice[DroppedPackets].width},
 			column{formatInt(int64(entry.MemoryUsedPercent)), headerSlice[MemoryUsage].width},
 			column{formatInt(int64(entry.CPUUsedPercent)), headerSlice[CPUUsage].width},
+			column{formatInt(int64(entry.CPUCoreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.ProcessCPU)), headerSlice[ProcessCPU].width},
+		)
+	case shared.UDPTest:
+		PrintColumns(
+			style,
+			column{entry.Created.Format("15:04:05"), headerSlice[Created].width},
+			column{hostOf(entry.Local), headerSlice[Local].width},
+			column{hostOf(entry.Remote), headerSlice[Remote].width},
+			column{shared.BWToString(entry.TX), headerSlice[TX].width},
+			column{formatUint(entry.TXCount), headerSlice[TXCount].width},
+			column{formatUint(entry.PacketsReceived), headerSlice[PacketsReceived].width},
+			column{formatUint(entry.PacketsLost), headerSlice[PacketsLost].width},
+			column{formatLoss(entry.PacketsLost, entry.PacketsReceived), headerSlice[LossPercent].width},
+			column{formatUint(entry.PacketsReordered), headerSlice[PacketsReordered].width},
+			column{formatUint(entry.PacketsDuplicate), headerSlice[PacketsDuplicate].width},
+			column{formatInt(entry.Jitter), headerSlice[Jitter].width},
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatInt(int64(entry.DroppedPackets)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MemoryUsedPercent)), headerSlice[MemoryUsage].width},
+			column{formatInt(int64(entry.CPUUsedPercent)), headerSlice[CPUUsage].width},
+			column{formatInt(int64(entry.CPUCoreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.ProcessCPU)), headerSlice[ProcessCPU].width},
+		)
+	case shared.ChurnTest:
+		PrintColumns(
+			style,
+			column{entry.Created.Format("15:04:05"), headerSlice[Created].width},
+			column{hostOf(entry.Local), headerSlice[Local].width},
+			column{hostOf(entry.Remote), headerSlice[Remote].width},
+			column{entry.Direction.String(), headerSlice[Direction].width},
+			column{formatUint(entry.ConnsPerSecond), headerSlice[ConnsPerSecond].width},
+			column{formatInt(entry.Phases.ConnectAvg()), headerSlice[PhaseConnect].width},
+			column{formatInt(entry.Phases.ConnectHigh()), headerSlice[ConnectHigh].width},
+			column{formatUint(entry.ConnPortExhausted), headerSlice[ConnPortExhausted].width},
+			column{formatUint(entry.ConnTimeouts), headerSlice[ConnTimeouts].width},
+			column{formatUint(entry.ConnRefused), headerSlice[ConnRefused].width},
+			column{formatUint(entry.ConnReset), headerSlice[ConnReset].width},
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatInt(int64(entry.DroppedPackets)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MemoryUsedPercent)), headerSlice[MemoryUsage].width},
+			column{formatInt(int64(entry.CPUUsedPercent)), headerSlice[CPUUsage].width},
+			column{formatInt(int64(entry.CPUCoreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.ProcessCPU)), headerSlice[ProcessCPU].width},
+		)
+	case shared.MTUTest:
+		PrintColumns(
+			style,
+			column{entry.Created.Format("15:04:05"), headerSlice[Created].width},
+			column{hostOf(entry.Local), headerSlice[Local].width},
+			column{hostOf(entry.Remote), headerSlice[Remote].width},
+			column{formatUint(entry.TXCount), headerSlice[TXCount].width},
+			column{formatInt(int64(entry.PathMTU)), headerSlice[PathMTU].width},
+			column{formatInt(int64(entry.InterfaceMTU)), headerSlice[InterfaceMTU].width},
+			column{formatInt(int64(entry.KernelMTU)), headerSlice[KernelMTU].width},
+			column{formatInt(int64(entry.ErrCount)), headerSlice[ErrCount].width},
+			column{formatInt(int64(entry.DroppedPackets)), headerSlice[DroppedPackets].width},
+			column{formatInt(int64(entry.MemoryUsedPercent)), headerSlice[MemoryUsage].width},
+			column{formatInt(int64(entry.CPUUsedPercent)), headerSlice[CPUUsage].width},
+			column{formatInt(int64(entry.CPUCoreHigh)), headerSlice[CPUCoreHigh].width},
+			column{formatInt(int64(entry.ProcessCPU)), headerSlice[ProcessCPU].width},
 		)
 	default:
 		shared.DEBUG("Unknown test type, not printing table")
 	}
 }
 
-func collectDataPointv2(r *shared.DataReponseToClient) {
+func collectDataPointv2(r *shared.DataReponseToClient, offset time.Duration) {
 	if r == nil {
 		return
 	}
+	correctDataPoints(r.DPS, offset)
+	correctHosts(r.Hosts, offset)
+	correctErrors(r.Errors, offset)
 
 	responseLock.Lock()
 	defer responseLock.Unlock()
 
 	responseDPS = append(responseDPS, r.DPS...)
+	responseHosts = append(responseHosts, r.Hosts...)
 	responseERR = append(responseERR, r.Errors...)
 }
 
@@ -333,6 +736,7 @@ func praseDataPoint(r *shared.DataReponseToClient, c *shared.Config) {
 	}
 
 	responseDPS = append(responseDPS, r.DPS...)
+	responseHosts = append(responseHosts, r.Hosts...)
 	responseERR = append(responseERR, r.Errors...)
 }
 
@@ -344,3 +748,15 @@ func formatInt(val int64) string {
 func formatUint(val uint64) string {
 	return strconv.FormatUint(val, 10)
 }
+
+func formatRate(val float64) string {
+	return strconv.FormatFloat(val, 'f', 1, 64)
+}
+
+// formatLoss returns the share of lost packets out of all packets that were expected
+func formatLoss(lost uint64, received uint64) string {
+	if lost+received == 0 {
+		return "0.00"
+	}
+	return strconv.FormatFloat(float64(lost)/float64(lost+received)*100, 'f', 2, 64)
+}
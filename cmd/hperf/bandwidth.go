@@ -34,9 +34,21 @@ var bandwidthCMD = cli.Command{
 		portFlag,
 		durationFlag,
 		saveTestFlag,
+		payloadContentFlag,
+		verifyFlag,
 		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
 		concurrencyFlag,
+		rampFlag,
+		rampStepFlag,
 		dnsServerFlag,
+		directionFlag,
 		microSecondsFlag,
 		printAllFlag,
 	},
@@ -61,6 +73,24 @@ EXAMPLES:
 
   3. Run a 30 seconds bandwidth test:
    {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --duration 30 --id bandwidth-30
+
+  4. Run a bandwidth test in both directions to find asymmetric links:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --direction both
+
+  5. Run a bandwidth test which verifies every byte to find silent corruption:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --direction both --verify
+
+  6. Run a bandwidth test with incompressible payloads through a WAN optimizer or compressing VPN:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --payload-content random
+
+  7. Find the concurrency where throughput stops growing, 10 seconds per step:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --ramp 1,2,4,8,16,32,64
+
+  8. Ramp the concurrency and then the payload size, 5 seconds per step:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --ramp 4,8,16,16:1MB,16:4MB --ramp-step 5
+
+  9. Compare congestion control algorithms with large kernel socket buffers:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --congestion bbr --socket-send-buffer 16MB --socket-receive-buffer 16MB
 `,
 }
 
@@ -76,8 +106,20 @@ func runBandwidth(ctx *cli.Context) error {
 	config.RequestDelay = 0
 	config.RestartOnError = true
 
+	config.Ramp, err = shared.ParseRamp(ctx.String(rampFlag.Name), config.PayloadSize)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if len(config.Ramp) > 0 {
+		config.RampStepDuration = ctx.Int(rampStepFlag.Name)
+		config.Duration = len(config.Ramp) * config.RampStepDuration
+	}
+
 	fmt.Println("")
 	shared.INFO(" Test ID:", config.TestID)
+	if len(config.Ramp) > 0 {
+		shared.INFO(" Ramp:", len(config.Ramp), "steps of", config.RampStepDuration, "seconds")
+	}
 	fmt.Println("")
 
 	err = client.RunTest(GlobalContext, *config)
@@ -0,0 +1,115 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+	"github.com/minio/hperf/shared"
+)
+
+var churnCMD = cli.Command{
+	Name:   "churn",
+	Usage:  "Start a test which opens a new connection for every request to measure connection setup",
+	Action: runChurn,
+	Flags: []cli.Flag{
+		hostsFlag,
+		portFlag,
+		durationFlag,
+		concurrencyFlag,
+		delayFlag,
+		rateFlag,
+		payloadSizeFlag,
+		saveTestFlag,
+		payloadContentFlag,
+		verifyFlag,
+		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
+		dnsServerFlag,
+		directionFlag,
+		microSecondsFlag,
+		printAllFlag,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+  Keep-alive is disabled, every request opens a new TCP connection and, when --insecure=false,
+  a new TLS session. The test reports connections per second, connect latency and connections which
+  failed because the ephemeral ports ran out, the SYN was not answered or the peer refused or reset it.
+  The payload size defaults to 1000 bytes for this test.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Open as many connections as possible with 10 concurrent requests per host:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --concurrency 10
+
+  2. Open about 100 connections per second to every host:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --concurrency 1 --request-delay 10
+
+  3. Measure TLS connection setup:
+   {{.Prompt}} hperf --insecure=false --tls-ca /path/to/ca.crt churn --hosts 10.10.10.1,10.10.10.2
+
+  4. Open 200 connections per second to every host, even when connects slow down:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --concurrency 50 --rate 200
+`,
+}
+
+func runChurn(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	config.TestType = shared.ChurnTest
+	config.RestartOnError = true
+	if !ctx.IsSet(payloadSizeFlag.Name) {
+		config.PayloadSize = 1000
+	}
+	err = parseRate(ctx, config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Test ID:", config.TestID)
+	fmt.Println("")
+
+	err = client.RunTest(GlobalContext, *config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Testing finished..")
+
+	return client.AnalyzeChurnTest(GlobalContext, *config)
+}
@@ -34,8 +34,19 @@ var latency = cli.Command{
 		portFlag,
 		durationFlag,
 		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
 		saveTestFlag,
+		payloadContentFlag,
+		verifyFlag,
 		dnsServerFlag,
+		directionFlag,
+		rateFlag,
 		microSecondsFlag,
 		printAllFlag,
 	},
@@ -54,6 +65,15 @@ EXAMPLES:
 
   2. Run a 30 second latency test with custom id:
    {{.Prompt}} {{.HelpName}} --duration 60 --hosts 10.10.10.1,10.10.10.2 --id latency-60
+
+  3. Run a latency test where every server fetches data from its peers and sends data to them:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --direction both
+
+  4. Run an open-loop latency test which sends 500 requests per second to every host:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --rate 500
+
+  5. Run a latency test which also measures the one-way delay in each direction:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --clock-sync
 `,
 }
 
@@ -68,6 +88,10 @@ func runLatency(ctx *cli.Context) error {
 	config.Concurrency = 1
 	config.RequestDelay = 200
 	config.RestartOnError = true
+	err = parseRate(ctx, config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
 
 	fmt.Println("")
 	shared.INFO(" Test ID:", config.TestID)
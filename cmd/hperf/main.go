@@ -53,6 +53,7 @@ GLOBAL FLAGS:
 `
 
 func main() {
+	shared.Version = version
 	CreateApp().Run(os.Args)
 }
 
@@ -61,9 +62,12 @@ func InvalidFlagValueError(value interface{}, name string) error {
 }
 
 var (
-	debug       = false
-	insecure    = false
-	globalFlags = []cli.Flag{
+	debug          = false
+	insecure       = false
+	tlsCA          = ""
+	tlsFingerprint = ""
+	authKey        = ""
+	globalFlags    = []cli.Flag{
 		hostsFlag,
 		portFlag,
 		insecureFlag,
@@ -76,6 +80,9 @@ var (
 		testIDFlag,
 		saveTestFlag,
 		dnsServerFlag,
+		tlsCAFlag,
+		tlsFingerprintFlag,
+		authKeyFlag,
 	}
 	hostsFlag = cli.StringFlag{
 		Name:   "hosts",
@@ -93,6 +100,21 @@ var (
 		EnvVar: "HPERF_INSECURE",
 		Usage:  "use http instead of https",
 	}
+	tlsCAFlag = cli.StringFlag{
+		Name:   "tls-ca",
+		EnvVar: "HPERF_TLS_CA",
+		Usage:  "path to a PEM encoded CA bundle used to verify server certificates",
+	}
+	tlsFingerprintFlag = cli.StringFlag{
+		Name:   "tls-fingerprint",
+		EnvVar: "HPERF_TLS_FINGERPRINT",
+		Usage:  "comma separated list of pinned server certificate SHA-256 fingerprints",
+	}
+	authKeyFlag = cli.StringFlag{
+		Name:   "auth-key",
+		EnvVar: "HPERF_AUTH_KEY",
+		Usage:  "shared secret used to authenticate clients and servers",
+	}
 	debugFlag = cli.BoolFlag{
 		Name:   "debug",
 		EnvVar: "HPERF_DEBUG",
@@ -128,6 +150,112 @@ var (
 		EnvVar: "HPERF_PAYLOAD_SIZE",
 		Usage:  "payload size in bytes",
 	}
+	directionFlag = cli.StringFlag{
+		Name:   "direction",
+		EnvVar: "HPERF_DIRECTION",
+		Value:  string(shared.DirectionUpload),
+		Usage:  "direction of the test traffic between servers: upload, download or both",
+	}
+	tcpPortFlag = cli.StringFlag{
+		Name:   "tcp-port",
+		EnvVar: "HPERF_TCP_PORT",
+		Usage:  "port of the raw TCP test listener, defaults to --port + 1",
+	}
+	packetRateFlag = cli.IntFlag{
+		Name:   "packet-rate",
+		Value:  1000,
+		EnvVar: "HPERF_PACKET_RATE",
+		Usage:  "datagrams per second sent to each host",
+	}
+	packetSizeFlag = cli.IntFlag{
+		Name:   "packet-size",
+		Value:  1200,
+		EnvVar: "HPERF_PACKET_SIZE",
+		Usage:  "datagram size in bytes, small values are increased to fit the packet header",
+	}
+	payloadContentFlag = cli.StringFlag{
+		Name:   "payload-content",
+		EnvVar: "HPERF_PAYLOAD_CONTENT",
+		Value:  string(shared.ContentZero),
+		Usage:  "what payloads are filled with: zero, random or ratio:N for data which compresses to about 1/N of its size",
+	}
+	rateFlag = cli.StringFlag{
+		Name:   "rate",
+		EnvVar: "HPERF_RATE",
+		Usage:  "send requests to every host on a fixed schedule, in requests per second (500) or bytes per second (100MB/s)",
+	}
+	rampFlag = cli.StringFlag{
+		Name:   "ramp",
+		EnvVar: "HPERF_RAMP",
+		Usage:  "step through a comma separated schedule of concurrency with optional payload size (1,2,4,8:1MB) and find where throughput stops growing",
+	}
+	rampStepFlag = cli.IntFlag{
+		Name:   "ramp-step",
+		Value:  10,
+		EnvVar: "HPERF_RAMP_STEP",
+		Usage:  "seconds every step of --ramp runs for, replaces --duration",
+	}
+	sweepTestFlag = cli.StringFlag{
+		Name:   "test",
+		Value:  "bandwidth",
+		EnvVar: "HPERF_SWEEP_TEST",
+		Usage:  "test every combination of the sweep runs: bandwidth or requests",
+	}
+	sweepConcurrencyFlag = cli.StringFlag{
+		Name:   "sweep-concurrency",
+		EnvVar: "HPERF_SWEEP_CONCURRENCY",
+		Usage:  "comma separated list of concurrency values to sweep (1,4,16), defaults to --concurrency",
+	}
+	sweepPayloadSizeFlag = cli.StringFlag{
+		Name:   "sweep-payload-size",
+		EnvVar: "HPERF_SWEEP_PAYLOAD_SIZE",
+		Usage:  "comma separated list of payload sizes to sweep (32KB,1MB), defaults to --payload-size",
+	}
+	sweepBufferSizeFlag = cli.StringFlag{
+		Name:   "sweep-buffer-size",
+		EnvVar: "HPERF_SWEEP_BUFFER_SIZE",
+		Usage:  "comma separated list of buffer sizes to sweep (32KB,256KB), defaults to --buffer-size",
+	}
+	verifyFlag = cli.BoolFlag{
+		Name:   "verify",
+		EnvVar: "HPERF_VERIFY",
+		Usage:  "fill payloads with a seeded pattern and verify every received byte, costs CPU on both sides",
+	}
+	sendBufferFlag = cli.StringFlag{
+		Name:   "socket-send-buffer",
+		EnvVar: "HPERF_SOCKET_SEND_BUFFER",
+		Usage:  "kernel send buffer (SO_SNDBUF) of test sockets (4MB), defaults to the kernel setting",
+	}
+	receiveBufferFlag = cli.StringFlag{
+		Name:   "socket-receive-buffer",
+		EnvVar: "HPERF_SOCKET_RECEIVE_BUFFER",
+		Usage:  "kernel receive buffer (SO_RCVBUF) of test sockets (4MB), defaults to the kernel setting",
+	}
+	congestionFlag = cli.StringFlag{
+		Name:   "congestion",
+		EnvVar: "HPERF_CONGESTION",
+		Usage:  "TCP congestion control of test sockets (cubic, bbr, reno), defaults to the kernel setting",
+	}
+	mssFlag = cli.IntFlag{
+		Name:   "mss",
+		EnvVar: "HPERF_MSS",
+		Usage:  "TCP maximum segment size (TCP_MAXSEG) of test sockets in bytes, defaults to the path MTU",
+	}
+	tcpNoDelayFlag = cli.BoolTFlag{
+		Name:   "tcp-nodelay",
+		EnvVar: "HPERF_TCP_NODELAY",
+		Usage:  "send small segments right away (TCP_NODELAY), set to false to enable Nagle's algorithm",
+	}
+	tosFlag = cli.StringFlag{
+		Name:   "tos",
+		EnvVar: "HPERF_TOS",
+		Usage:  "IP TOS byte (0xb8) or DSCP class (ef, af41, cs1) of test packets",
+	}
+	clockSyncFlag = cli.BoolFlag{
+		Name:   "clock-sync",
+		EnvVar: "HPERF_CLOCK_SYNC",
+		Usage:  "estimate the clock offset between servers and derive the delay in each direction from it",
+	}
 	restartOnErrorFlag = cli.BoolTFlag{
 		Name:   "restart-on-error",
 		EnvVar: "HPERF_RESTART_ON_ERROR",
@@ -181,19 +309,29 @@ var (
 	baseFlags = []cli.Flag{
 		debugFlag,
 		insecureFlag,
+		tlsCAFlag,
+		tlsFingerprintFlag,
+		authKeyFlag,
 	}
 	Commands = []cli.Command{
 		analyzeCMD,
 		bandwidthCMD,
+		churnCMD,
 		csvCMD,
 		deleteCMD,
 		latency,
 		listenCMD,
 		listTestsCMD,
+		mtuCMD,
 		requestsCMD,
 		serverCMD,
+		shutdownCMD,
 		statDownloadCMD,
+		statusCMD,
 		stopCMD,
+		sweepCMD,
+		tcpCMD,
+		udpCMD,
 	}
 )
 
@@ -235,6 +373,9 @@ var (
 func before(ctx *cli.Context) error {
 	debug = ctx.Bool("debug")
 	insecure = ctx.Bool("insecure")
+	tlsCA = ctx.String(tlsCAFlag.Name)
+	tlsFingerprint = ctx.String(tlsFingerprintFlag.Name)
+	authKey = ctx.String(authKeyFlag.Name)
 	GlobalContext, GlobalCancelFunc = context.WithCancelCause(context.Background())
 	go handleOSSignal(GlobalCancelFunc)
 	return nil
@@ -261,31 +402,59 @@ func parseConfig(ctx *cli.Context) (*shared.Config, error) {
 	}
 
 	config = &shared.Config{
-		DialTimeout:    0,
-		Debug:          debug,
-		Hosts:          hosts,
-		Insecure:       insecure,
-		TestType:       shared.RequestTest,
-		Duration:       ctx.Int(durationFlag.Name),
-		RequestDelay:   ctx.Int(delayFlag.Name),
-		Concurrency:    ctx.Int(concurrencyFlag.Name),
-		PayloadSize:    ctx.Int(payloadSizeFlag.Name),
-		BufferSize:     ctx.Int(bufferSizeFlag.Name),
-		Port:           ctx.String(portFlag.Name),
-		Save:           ctx.BoolT(saveTestFlag.Name),
-		TestID:         ctx.String(testIDFlag.Name),
-		RestartOnError: ctx.BoolT(restartOnErrorFlag.Name),
-		File:           ctx.String(fileFlag.Name),
-		PrintStats:     ctx.Bool(printStatsFlag.Name),
-		PrintAll:       ctx.Bool(printAllFlag.Name),
-		PrintErrors:    ctx.Bool(printErrFlag.Name),
-		Sort:           shared.SortType(ctx.String(sortFlag.Name)),
-		Micro:          ctx.Bool(microSecondsFlag.Name),
-		HostFilter:     ctx.String(hostFilterFlag.Name),
+		DialTimeout:     0,
+		Debug:           debug,
+		Hosts:           hosts,
+		Insecure:        insecure,
+		TestType:        shared.RequestTest,
+		Duration:        ctx.Int(durationFlag.Name),
+		RequestDelay:    ctx.Int(delayFlag.Name),
+		Concurrency:     ctx.Int(concurrencyFlag.Name),
+		PayloadSize:     ctx.Int(payloadSizeFlag.Name),
+		BufferSize:      ctx.Int(bufferSizeFlag.Name),
+		Port:            ctx.String(portFlag.Name),
+		Save:            ctx.BoolT(saveTestFlag.Name),
+		TestID:          ctx.String(testIDFlag.Name),
+		RestartOnError:  ctx.BoolT(restartOnErrorFlag.Name),
+		Verify:          ctx.Bool(verifyFlag.Name),
+		ClockSync:       ctx.Bool(clockSyncFlag.Name),
+		File:            ctx.String(fileFlag.Name),
+		PrintStats:      ctx.Bool(printStatsFlag.Name),
+		PrintAll:        ctx.Bool(printAllFlag.Name),
+		PrintErrors:     ctx.Bool(printErrFlag.Name),
+		Sort:            shared.SortType(ctx.String(sortFlag.Name)),
+		Micro:           ctx.Bool(microSecondsFlag.Name),
+		HostFilter:      ctx.String(hostFilterFlag.Name),
+		TLSCA:           tlsCA,
+		TLSFingerprints: shared.ParseFingerprints(tlsFingerprint),
+		AuthKey:         authKey,
+	}
+
+	config.Direction, err = shared.ParseDirection(ctx.String(directionFlag.Name))
+	if err != nil {
+		goto Error
+	}
+
+	config.PayloadContent, err = shared.ParsePayloadContent(ctx.String(payloadContentFlag.Name))
+	if err != nil {
+		goto Error
+	}
+	if config.Verify {
+		// verified payloads are filled with the pattern
+		if ctx.IsSet(payloadContentFlag.Name) {
+			err = fmt.Errorf("--verify fills payloads with a seeded pattern and can not be used with --payload-content")
+			goto Error
+		}
+		config.PayloadContent = shared.ContentPattern
+	}
+
+	config.Socket, err = parseSocketOptions(ctx)
+	if err != nil {
+		goto Error
 	}
 
 	switch ctx.Command.Name {
-	case "latency", "bandwidth", "http", "get":
+	case "latency", "bandwidth", "udp", "tcp", "churn", "sweep", "mtu", "http", "get":
 		if ctx.String("id") == "" {
 			config.TestID = strconv.Itoa(int(time.Now().Unix()))
 		}
@@ -317,6 +486,48 @@ Error:
 	return config, nil
 }
 
+// parseRate sets the open-loop request rate, it has to be called once the
+// payload size is final because rates in bytes are turned into requests.
+func parseRate(ctx *cli.Context, config *shared.Config) (err error) {
+	config.Rate, err = shared.ParseRate(ctx.String(rateFlag.Name), config.PayloadSize)
+	if err != nil || config.Rate == 0 {
+		return err
+	}
+	// the schedule replaces the delay, and enough requests
+	// have to be in flight to keep up with it.
+	config.RequestDelay = 0
+	if !ctx.IsSet(concurrencyFlag.Name) {
+		config.Concurrency = concurrencyFlag.Value
+	}
+	return nil
+}
+
+// parseSocketOptions returns the socket options of TCP tests,
+// options which are not set keep the kernel defaults.
+func parseSocketOptions(ctx *cli.Context) (o shared.SocketOptions, err error) {
+	if s := ctx.String(sendBufferFlag.Name); s != "" {
+		o.SendBuffer, err = shared.ParseSize(s)
+		if err != nil {
+			return o, err
+		}
+	}
+	if s := ctx.String(receiveBufferFlag.Name); s != "" {
+		o.ReceiveBuffer, err = shared.ParseSize(s)
+		if err != nil {
+			return o, err
+		}
+	}
+	o.TOS, err = shared.ParseTOS(ctx.String(tosFlag.Name))
+	if err != nil {
+		return o, err
+	}
+	o.Congestion = ctx.String(congestionFlag.Name)
+	o.MaxSegment = ctx.Int(mssFlag.Name)
+	// the flag does not exist on tests without TCP sockets
+	o.Nagle = ctx.IsSet(tcpNoDelayFlag.Name) && !ctx.BoolT(tcpNoDelayFlag.Name)
+	return o, o.Validate()
+}
+
 func prettyprint(data *shared.Config, title string) {
 	if !data.Debug {
 		return
@@ -0,0 +1,93 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+	"github.com/minio/hperf/shared"
+)
+
+var mtuCMD = cli.Command{
+	Name:   "mtu",
+	Usage:  "Start a test which probes the path MTU between all servers",
+	Action: runMTU,
+	Flags: []cli.Flag{
+		hostsFlag,
+		portFlag,
+		durationFlag,
+		testIDFlag,
+		clockSyncFlag,
+		saveTestFlag,
+		dnsServerFlag,
+		printAllFlag,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+  Every server sends UDP datagrams with the don't fragment bit set to its peers on the server port
+  and searches for the largest packet which gets through. The result is compared with the MTU of
+  the interface the probes leave from. Probing is only supported by servers running on linux.
+  The test runs for 10 seconds unless --duration is set.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Find MTU mismatches between all servers:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2,10.10.10.3
+
+  2. Watch the path MTU for 5 minutes to catch paths which change:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.{1...8} --duration 300 --id mtu-5m
+`,
+}
+
+func runMTU(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	config.TestType = shared.MTUTest
+	config.Concurrency = 1
+	config.RequestDelay = 0
+	config.RestartOnError = true
+	if !ctx.IsSet(durationFlag.Name) {
+		config.Duration = 10
+	}
+
+	fmt.Println("")
+	shared.INFO(" Test ID:", config.TestID)
+	fmt.Println("")
+
+	err = client.RunTest(GlobalContext, *config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Testing finished..")
+
+	return client.AnalyzeMTUTest(GlobalContext, *config)
+}
@@ -37,8 +37,18 @@ var requestsCMD = cli.Command{
 		payloadSizeFlag,
 		restartOnErrorFlag,
 		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
 		saveTestFlag,
+		payloadContentFlag,
+		verifyFlag,
 		dnsServerFlag,
+		rateFlag,
 		microSecondsFlag,
 	},
 	CustomHelpTemplate: `NAME:
@@ -62,6 +72,12 @@ EXAMPLES:
 
   4. Run a high throughput test with 1MB payload size:
    {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --request-delay 0 --concurrency 10 --payload-size 1000000
+
+  5. Run a test which verifies the payload of every request:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --verify
+
+  6. Run an open-loop test which sends 10MB/s of 1KB requests to every host:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --concurrency 32 --rate 10MB/s
 `,
 }
 
@@ -19,6 +19,7 @@ package main
 
 import (
 	"os"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/hperf/server"
@@ -49,12 +50,76 @@ var (
 		Value:  getPWD(),
 		Usage:  "all test results will be saved in this directory",
 	}
+	certFlag = cli.StringFlag{
+		Name:   "cert",
+		EnvVar: "HPERF_CERT",
+		Usage:  "path to a TLS certificate, enables TLS when used together with --key",
+	}
+	keyFlag = cli.StringFlag{
+		Name:   "key",
+		EnvVar: "HPERF_KEY",
+		Usage:  "path to the TLS private key for --cert",
+	}
+	retentionFlag = cli.DurationFlag{
+		Name:   "retention",
+		EnvVar: "HPERF_RETENTION",
+		Value:  time.Hour,
+		Usage:  "how long finished tests are kept in memory for the status command",
+	}
+	rotateSizeFlag = cli.Int64Flag{
+		Name:   "rotate-size",
+		EnvVar: "HPERF_ROTATE_SIZE",
+		Usage:  "start a new result file when the current one reaches this size in bytes, 0 disables size based rotation",
+	}
+	rotateIntervalFlag = cli.DurationFlag{
+		Name:   "rotate-interval",
+		EnvVar: "HPERF_ROTATE_INTERVAL",
+		Usage:  "start a new result file when the current one is older than this, 0 disables time based rotation",
+	}
+	compressFlag = cli.StringFlag{
+		Name:   "compress",
+		EnvVar: "HPERF_COMPRESS",
+		Value:  server.CompressNone,
+		Usage:  "compress closed result files: none, gzip or zstd",
+	}
+	serverTCPPortFlag = cli.StringFlag{
+		Name:   "tcp-port",
+		EnvVar: "HPERF_TCP_PORT",
+		Usage:  "listen for raw TCP tests on this port, defaults to the port of --address + 1",
+	}
+	interfaceFlag = cli.StringFlag{
+		Name:   "interface",
+		EnvVar: "HPERF_INTERFACE",
+		Usage:  "comma separated network interfaces reported in data points, 'auto' selects the interface of --real-ip or --address, all interfaces when empty, a '-' prefix ignores an interface",
+	}
+	selfSignedFlag = cli.BoolFlag{
+		Name:   "self-signed",
+		EnvVar: "HPERF_SELF_SIGNED",
+		Usage:  "generate a self-signed certificate in --storage-path and enable TLS",
+	}
 
 	serverCMD = cli.Command{
 		Name:   "server",
 		Usage:  "start an interactive server",
 		Action: runServer,
-		Flags:  []cli.Flag{addressFlag, realIPFlag, storagePathFlag, debugFlag},
+		Flags: []cli.Flag{
+			addressFlag,
+			realIPFlag,
+			storagePathFlag,
+			retentionFlag,
+			rotateSizeFlag,
+			rotateIntervalFlag,
+			compressFlag,
+			serverTCPPortFlag,
+			interfaceFlag,
+			certFlag,
+			keyFlag,
+			selfSignedFlag,
+			tlsCAFlag,
+			tlsFingerprintFlag,
+			authKeyFlag,
+			debugFlag,
+		},
 		CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -76,17 +141,58 @@ EXAMPLES:
 
   4. Run HPerf server with custom file path and floating(real) ip
     {{.Prompt}} {{.HelpName}} --storage-path /path/on/disk --address 0.0.0.0:9000 --real-ip 152.121.12.4
+
+  5. Run HPerf server with TLS using an existing certificate and key
+    {{.Prompt}} {{.HelpName}} --cert /path/to/public.crt --key /path/to/private.key --tls-ca /path/to/ca.crt
+
+  6. Run HPerf server with TLS using a generated self-signed certificate
+    {{.Prompt}} {{.HelpName}} --storage-path /path/on/disk --self-signed --tls-fingerprint <sha256>,<sha256>
+
+  7. Run HPerf server which only accepts commands signed with a shared secret
+    {{.Prompt}} HPERF_AUTH_KEY=my-secret {{.HelpName}} --storage-path /path/on/disk
+
+  8. Run HPerf server which rotates result files every 100MB or 10 minutes and compresses them
+    {{.Prompt}} {{.HelpName}} --storage-path /path/on/disk --rotate-size 104857600 --rotate-interval 10m --compress zstd
+
+  9. Run HPerf server which only reports the counters of the interface carrying the test traffic
+    {{.Prompt}} {{.HelpName}} --storage-path /path/on/disk --address 10.10.10.2:9000 --interface auto
 `,
 	}
 )
 
 func runServer(ctx *cli.Context) error {
 	shared.DebugEnabled = debug
+
+	// the TLS and auth flags can be set globally or on the server command
+	if ctx.IsSet(tlsCAFlag.Name) {
+		tlsCA = ctx.String(tlsCAFlag.Name)
+	}
+	if ctx.IsSet(tlsFingerprintFlag.Name) {
+		tlsFingerprint = ctx.String(tlsFingerprintFlag.Name)
+	}
+	if ctx.IsSet(authKeyFlag.Name) {
+		authKey = ctx.String(authKeyFlag.Name)
+	}
+
 	err := server.RunServer(
 		GlobalContext,
-		ctx.String("address"),
-		ctx.String("real-ip"),
-		ctx.String("storage-path"),
+		server.Options{
+			Address:        ctx.String(addressFlag.Name),
+			RealIP:         ctx.String(realIPFlag.Name),
+			StoragePath:    ctx.String(storagePathFlag.Name),
+			Retention:      ctx.Duration(retentionFlag.Name),
+			RotateSize:     ctx.Int64(rotateSizeFlag.Name),
+			RotateInterval: ctx.Duration(rotateIntervalFlag.Name),
+			Compression:    ctx.String(compressFlag.Name),
+			CertFile:       ctx.String(certFlag.Name),
+			KeyFile:        ctx.String(keyFlag.Name),
+			SelfSigned:     ctx.Bool(selfSignedFlag.Name),
+			CAFile:         tlsCA,
+			Fingerprints:   shared.ParseFingerprints(tlsFingerprint),
+			TCPPort:        ctx.String(serverTCPPortFlag.Name),
+			Interfaces:     ctx.String(interfaceFlag.Name),
+			AuthKey:        authKey,
+		},
 	)
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
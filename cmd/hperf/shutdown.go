@@ -0,0 +1,80 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+)
+
+var (
+	drainWaitFlag = cli.BoolFlag{
+		Name:  "wait",
+		Usage: "wait for running tests to finish instead of canceling them",
+	}
+
+	shutdownCMD = cli.Command{
+		Name:    "shutdown",
+		Aliases: []string{"drain"},
+		Usage:   "gracefully shut down the selected hosts",
+		Action:  runShutdown,
+		Flags: []cli.Flag{
+			dnsServerFlag,
+			hostsFlag,
+			portFlag,
+			drainWaitFlag,
+		},
+		CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+  The servers stop accepting new tests, cancel running tests (or wait for
+  them with --wait), close all result files and notify listening clients
+  before exiting.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Shut down hosts '10.10.10.1' and '10.10.10.2', canceling running tests:
+    {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2
+
+  2. Shut down hosts '10.10.10.1' and '10.10.10.2' once running tests finish:
+    {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --wait
+`,
+	}
+)
+
+func runShutdown(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	config.DrainWait = ctx.Bool(drainWaitFlag.Name)
+	if config.DrainWait {
+		// the hosts only reply once all tests have finished
+		config.Duration = math.MaxInt32
+	}
+	config.RestartOnError = false
+	return client.Shutdown(GlobalContext, *config)
+}
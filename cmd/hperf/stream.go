@@ -33,11 +33,20 @@ var streamCMD = cli.Command{
 		concurrencyFlag,
 		durationFlag,
 		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
 		bufferSizeFlag,
 		payloadSizeFlag,
 		restartOnErrorFlag,
 		dnsServerFlag,
 		saveTestFlag,
+		payloadContentFlag,
+		verifyFlag,
 	},
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+	"github.com/minio/hperf/shared"
+)
+
+var sweepCMD = cli.Command{
+	Name:   "sweep",
+	Usage:  "Run a test for every combination of concurrency, payload size and buffer size",
+	Action: runSweep,
+	Flags: []cli.Flag{
+		hostsFlag,
+		portFlag,
+		durationFlag,
+		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
+		saveTestFlag,
+		sweepTestFlag,
+		sweepConcurrencyFlag,
+		sweepPayloadSizeFlag,
+		sweepBufferSizeFlag,
+		concurrencyFlag,
+		payloadSizeFlag,
+		bufferSizeFlag,
+		delayFlag,
+		payloadContentFlag,
+		verifyFlag,
+		dnsServerFlag,
+		directionFlag,
+		microSecondsFlag,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+	Every combination runs for --duration seconds as a test of its own. The tests are named <id>-1, <id>-2 and so on and can be downloaded and analyzed one by one.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Find the best concurrency for a bandwidth test, 10 seconds per value:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --duration 10 --sweep-concurrency 1,2,4,8,16
+
+  2. Try every combination of concurrency and buffer size under one ID:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --id sweep-1 --sweep-concurrency 4,16 --sweep-buffer-size 32KB,256KB,1MB
+
+  3. Sweep the payload size of HTTP requests in both directions:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --test requests --direction both --sweep-payload-size 64KB,1MB,16MB
+`,
+}
+
+func runSweep(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	switch ctx.String(sweepTestFlag.Name) {
+	case "bandwidth":
+		config.TestType = shared.StreamTest
+		config.RequestDelay = 0
+		if !ctx.IsSet(payloadSizeFlag.Name) {
+			// same as the bandwidth command
+			config.PayloadSize = 32000
+		}
+	case "requests":
+		config.TestType = shared.RequestTest
+	default:
+		return cli.NewExitError(InvalidFlagValueError(ctx.String(sweepTestFlag.Name), sweepTestFlag.Name).Error(), 1)
+	}
+	config.RestartOnError = true
+
+	concurrency, err := shared.ParseSweepCounts(ctx.String(sweepConcurrencyFlag.Name), config.Concurrency)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	payloadSizes, err := shared.ParseSweepSizes(ctx.String(sweepPayloadSizeFlag.Name), config.PayloadSize)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	bufferSizes, err := shared.ParseSweepSizes(ctx.String(sweepBufferSizeFlag.Name), config.BufferSize)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	points, err := shared.SweepPoints(concurrency, payloadSizes, bufferSizes)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Sweep ID:", config.TestID)
+	shared.INFO(" Sweep:", len(points), "combinations of", config.Duration, "seconds")
+
+	err = client.RunSweep(GlobalContext, *config, points)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	return nil
+}
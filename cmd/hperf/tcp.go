@@ -0,0 +1,110 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+	"github.com/minio/hperf/shared"
+)
+
+var tcpCMD = cli.Command{
+	Name:   "tcp",
+	Usage:  "Start a test which writes directly to TCP connections to measure bandwidth without HTTP",
+	Action: runTCP,
+	Flags: []cli.Flag{
+		hostsFlag,
+		portFlag,
+		tcpPortFlag,
+		durationFlag,
+		concurrencyFlag,
+		bufferSizeFlag,
+		saveTestFlag,
+		payloadContentFlag,
+		testIDFlag,
+		clockSyncFlag,
+		sendBufferFlag,
+		receiveBufferFlag,
+		congestionFlag,
+		mssFlag,
+		tcpNoDelayFlag,
+		tosFlag,
+		dnsServerFlag,
+		printAllFlag,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+  Servers open a second listener for raw TCP tests, by default on the server port + 1. Every
+  connection writes --buffer-size bytes at a time until the test ends. The traffic is not encrypted,
+  even when the servers use TLS.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Run a raw TCP test with 10 connections per host:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --concurrency 10
+
+  2. Run a raw TCP test against servers with a custom raw TCP port:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --port 9010 --tcp-port 9020
+
+  3. Run a raw TCP test with payloads which compress to about half of their size:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --payload-content ratio:2
+
+  4. Run a raw TCP test with expedited forwarding DSCP marking and a 1400 byte segment size:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --tos ef --mss 1400
+`,
+}
+
+func runTCP(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	config.TestType = shared.TCPTest
+	config.TCPPort = ctx.String(tcpPortFlag.Name)
+	config.PayloadSize = config.BufferSize
+	config.RequestDelay = 0
+	config.RestartOnError = true
+
+	if config.BufferSize <= 0 {
+		return cli.NewExitError("--buffer-size needs to be larger than 0", 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Test ID:", config.TestID)
+	fmt.Println("")
+
+	err = client.RunTest(GlobalContext, *config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Testing finished..")
+
+	return client.AnalyzeBandwidthTest(GlobalContext, *config)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/hperf/client"
+	"github.com/minio/hperf/shared"
+)
+
+var udpCMD = cli.Command{
+	Name:   "udp",
+	Usage:  "Start a test which sends UDP datagrams to measure packet loss and jitter",
+	Action: runUDP,
+	Flags: []cli.Flag{
+		hostsFlag,
+		portFlag,
+		durationFlag,
+		testIDFlag,
+		clockSyncFlag,
+		packetRateFlag,
+		packetSizeFlag,
+		saveTestFlag,
+		payloadContentFlag,
+		dnsServerFlag,
+		printAllFlag,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+NOTES:
+  Every server sends sequence numbered datagrams to its peers on the server port. Loss, reordering,
+  duplicates and jitter are measured by the receiving server. Jitter is shown in microseconds.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Run a 30 second UDP test with the default rate and packet size:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2
+
+  2. Run a UDP test with 10000 packets per second of 9000 bytes each:
+   {{.Prompt}} {{.HelpName}} --hosts 10.10.10.1,10.10.10.2 --packet-rate 10000 --packet-size 9000
+`,
+}
+
+func runUDP(ctx *cli.Context) error {
+	config, err := parseConfig(ctx)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	config.TestType = shared.UDPTest
+	config.PacketRate = ctx.Int(packetRateFlag.Name)
+	config.PayloadSize = ctx.Int(packetSizeFlag.Name)
+	config.Concurrency = 1
+	config.RequestDelay = 0
+	config.RestartOnError = true
+
+	if config.PacketRate <= 0 || config.PacketRate > shared.MaxPacketRate {
+		return cli.NewExitError(fmt.Sprintf("--packet-rate needs to be between 1 and %d", shared.MaxPacketRate), 1)
+	}
+	if config.PayloadSize <= 0 || config.PayloadSize > shared.MaxDatagramSize {
+		return cli.NewExitError(fmt.Sprintf("--packet-size needs to be between 1 and %d", shared.MaxDatagramSize), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Test ID:", config.TestID)
+	fmt.Println("")
+
+	err = client.RunTest(GlobalContext, *config)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Println("")
+	shared.INFO(" Testing finished..")
+
+	return client.AnalyzeUDPTest(GlobalContext, *config)
+}
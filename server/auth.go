@@ -0,0 +1,151 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/hperf/shared"
+)
+
+var (
+	authKey = ""
+	// nonces of signals, peers use a token per test instead
+	signalNonces = newNonceCache()
+	// rejected requests are logged at most once per interval
+	// to avoid flooding the logs when a peer is misconfigured.
+	lastRejectLog   = make(map[string]time.Time)
+	rejectLogLock   = sync.Mutex{}
+	rejectLogPeriod = 10 * time.Second
+)
+
+var errReplayedSignal = errors.New("Replayed signal")
+
+func authenticateSignal(s *shared.WebsocketSignal) error {
+	if authKey == "" {
+		return nil
+	}
+	err := shared.VerifySignal(s, authKey)
+	if err != nil {
+		return err
+	}
+	if signalNonces.Seen(s.Nonce) {
+		return errReplayedSignal
+	}
+	return nil
+}
+
+// nonceCache remembers nonces for at least twice the allowed clock skew,
+// which covers every timestamp that passes the skew check. Old nonces are
+// dropped a generation at a time instead of scanning on every insert.
+type nonceCache struct {
+	m        sync.Mutex
+	current  map[string]struct{}
+	previous map[string]struct{}
+	rotated  time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{
+		current:  make(map[string]struct{}),
+		previous: make(map[string]struct{}),
+		rotated:  time.Now(),
+	}
+}
+
+// Seen records the nonce and reports if it was recorded before
+func (c *nonceCache) Seen(nonce string) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if time.Since(c.rotated) > 2*shared.AuthMaxSkew {
+		c.previous = c.current
+		c.current = make(map[string]struct{})
+		c.rotated = time.Now()
+	}
+	_, seen := c.current[nonce]
+	if !seen {
+		_, seen = c.previous[nonce]
+	}
+	if seen {
+		return true
+	}
+	c.current[nonce] = struct{}{}
+	return false
+}
+
+func authenticatePeer(c *fiber.Ctx) error {
+	if authKey == "" {
+		return c.Next()
+	}
+	err := shared.PeerAuth{
+		TestID:    c.Get(shared.TestHeader),
+		Timestamp: c.Get(shared.AuthTimestampHeader),
+	}.Verify(authKey, c.Get(shared.AuthSignatureHeader))
+	if err != nil {
+		logRejected(c.IP(), c.Path(), err)
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	return c.Next()
+}
+
+type peerToken struct {
+	created   time.Time
+	timestamp string
+	signature string
+}
+
+// peerToken is the signed token of a test, it is renewed after half of
+// the allowed clock skew so peers never see an expired one.
+func (t *test) peerToken() (timestamp string, signature string) {
+	t.tokenLock.Lock()
+	defer t.tokenLock.Unlock()
+	if t.token.signature == "" || time.Since(t.token.created) > shared.AuthMaxSkew/2 {
+		p := shared.NewPeerAuth(t.ID)
+		t.token.created = time.Now()
+		t.token.timestamp = p.Timestamp
+		t.token.signature = p.Signature(authKey)
+	}
+	return t.token.timestamp, t.token.signature
+}
+
+// signPeerRequest names the test of a request and adds its token
+func signPeerRequest(req *http.Request, t *test) {
+	req.Header.Set(shared.TestHeader, t.ID)
+	if authKey == "" {
+		return
+	}
+	ts, sig := t.peerToken()
+	req.Header.Set(shared.AuthTimestampHeader, ts)
+	req.Header.Set(shared.AuthSignatureHeader, sig)
+}
+
+func logRejected(remote string, route string, err error) {
+	rejectLogLock.Lock()
+	defer rejectLogLock.Unlock()
+	key := remote + route
+	if time.Since(lastRejectLog[key]) < rejectLogPeriod {
+		return
+	}
+	lastRejectLog[key] = time.Now()
+	log.Println("Rejected unauthenticated request from", remote, "on", route+":", err)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/minio/hperf/shared"
+)
+
+// connFailures counts failed requests by the reason the connection failed
+type connFailures struct {
+	portExhausted uint64
+	timeouts      uint64
+	refused       uint64
+	reset         uint64
+	other         uint64
+}
+
+// countConnFailure sorts a request error into the failures that matter
+// when opening many connections: running out of ephemeral ports, SYNs
+// which are never answered, and peers or middleboxes refusing or
+// resetting connections.
+func (r *netPerfReader) countConnFailure(err error) {
+	var opErr *net.OpError
+	dial := errors.As(err, &opErr) && opErr.Op == "dial"
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	switch {
+	case errors.Is(err, syscall.EADDRNOTAVAIL), errors.Is(err, syscall.EADDRINUSE):
+		r.failures.portExhausted++
+	case errors.Is(err, syscall.ETIMEDOUT), dial && opErr.Timeout():
+		r.failures.timeouts++
+	case errors.Is(err, syscall.ECONNREFUSED):
+		r.failures.refused++
+	case errors.Is(err, syscall.ECONNRESET):
+		r.failures.reset++
+	default:
+		r.failures.other++
+	}
+	r.hasStats = true
+}
+
+func (f *connFailures) addTo(d *shared.DP) {
+	d.ConnPortExhausted = f.portExhausted
+	d.ConnTimeouts = f.timeouts
+	d.ConnRefused = f.refused
+	d.ConnReset = f.reset
+	d.ConnOtherErrors = f.other
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	clockProbeInterval = 200 * time.Millisecond
+	clockProbeTimeout  = time.Second
+)
+
+// peerClock holds the clock probes of one peer, they are exchanged once
+// per peer and test no matter how many readers the peer has.
+type peerClock struct {
+	m sync.Mutex
+	// the samples of the whole test and of the current interval
+	estimator shared.ClockEstimator
+	interval  []shared.ClockSample
+	collected time.Time
+}
+
+// startClockSync starts the clock probes to a peer unless they are
+// already running for the test.
+func (t *test) startClockSync(addr string) {
+	t.clocksLock.Lock()
+	defer t.clocksLock.Unlock()
+	if _, ok := t.clocks[addr]; ok {
+		return
+	}
+	if t.clocks == nil {
+		t.clocks = make(map[string]*peerClock)
+	}
+	c := new(peerClock)
+	t.clocks[addr] = c
+	go syncClock(t, addr, c)
+}
+
+func (t *test) peerClock(addr string) *peerClock {
+	t.clocksLock.Lock()
+	defer t.clocksLock.Unlock()
+	return t.clocks[addr]
+}
+
+// syncClock exchanges clock probes with a peer over UDP until the test ends.
+func syncClock(t *test, addr string, c *peerClock) {
+	defer func() {
+		rec := recover()
+		if rec != nil {
+			log.Println(rec, string(debug.Stack()))
+		}
+	}()
+
+	d := &net.Dialer{
+		LocalAddr: localUDPAddr(),
+		Timeout:   10 * time.Second,
+	}
+	con, err := d.DialContext(t.ctx, "udp", addr)
+	if err != nil {
+		t.AddError(fmt.Errorf("Unable to start clock sync with %s: %s", addr, err), "clock-dial")
+		return
+	}
+	defer con.Close()
+
+	probe := shared.ClockProbe{TestID: t.ID}
+	buf := make([]byte, shared.ClockProbeSize(t.ID))
+	ticker := time.NewTicker(clockProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		probe.Sequence++
+		probe.T1 = time.Now().UnixNano()
+		_, err = probe.Encode(buf, authKey)
+		if err != nil {
+			t.AddError(err, "clock-encode")
+			return
+		}
+		_, err = con.Write(buf)
+		if err != nil {
+			t.AddError(fmt.Errorf("Unable to send clock probe to %s: %s", addr, err), "clock-write-"+addr)
+			continue
+		}
+
+		s, ok := readClockReply(con, probe)
+		if !ok {
+			continue
+		}
+		c.m.Lock()
+		c.estimator.Add(s)
+		c.interval = append(c.interval, s)
+		c.m.Unlock()
+	}
+}
+
+func readClockReply(con net.Conn, probe shared.ClockProbe) (s shared.ClockSample, ok bool) {
+	con.SetReadDeadline(time.Now().Add(clockProbeTimeout))
+	buf := make([]byte, shared.ClockProbeSize(probe.TestID))
+	for {
+		n, err := con.Read(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return s, false
+		}
+		if err != nil {
+			shared.DEBUG("Error reading clock probe:", err)
+			// refused probes fail right away, wait for the next one
+			return s, false
+		}
+		t4 := time.Now().UnixNano()
+		reply, err := shared.DecodeClockProbe(buf[:n], authKey)
+		if err != nil || !reply.Reply || reply.Sequence != probe.Sequence {
+			// replies to earlier probes
+			continue
+		}
+		return shared.ClockSample{T1: reply.T1, T2: reply.T2, T3: reply.T3, T4: t4}, true
+	}
+}
+
+// answerClockProbe sends the probe back with the time it arrived
+// and the time the answer was sent.
+func answerClockProbe(b []byte, from netip.AddrPort, arrived time.Time) {
+	probe, err := shared.DecodeClockProbe(b, authKey)
+	if err != nil {
+		logRejected(from.String(), "udp", err)
+		return
+	}
+	t, ok := tests.Get(probe.TestID)
+	if probe.Reply || !ok || t.ctx.Err() != nil {
+		return
+	}
+	probe.Reply = true
+	probe.T2 = arrived.UnixNano()
+	buf := make([]byte, shared.ClockProbeSize(probe.TestID))
+	probe.T3 = time.Now().UnixNano()
+	_, err = probe.Encode(buf, authKey)
+	if err != nil {
+		return
+	}
+	_, err = udpConn.WriteToUDPAddrPort(buf, from)
+	if err != nil {
+		shared.DEBUG("Error answering clock probe:", err)
+	}
+}
+
+// collect adds the clock offset, the probe RTT and the one-way delays
+// of the interval to the data point. Only the first data point of the
+// peer in an interval gets them. The one-way delays are derived from the
+// RTT and the offset, which assumes the fastest probes took the same time
+// in both directions, they are not measured on their own.
+func (c *peerClock) collect(d *shared.DP, now time.Time) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if now.Equal(c.collected) {
+		return
+	}
+	c.collected = now
+	m, ok := c.estimator.Estimate()
+	if !ok {
+		return
+	}
+	d.ClockOffset = m.At(now.UnixNano()) / 1000
+	d.ClockDrift = m.DriftPPM()
+	d.ClockSamples = uint64(len(c.interval))
+	if len(c.interval) == 0 {
+		return
+	}
+	var rtt, out, back int64
+	for _, s := range c.interval {
+		rtt += s.Delay()
+		o, b := s.OneWay(m.At(s.T1))
+		out += o
+		back += b
+	}
+	d.ClockRTT = rtt / int64(len(c.interval)) / 1000
+	d.OneWayOut = out / int64(len(c.interval)) / 1000
+	d.OneWayBack = back / int64(len(c.interval)) / 1000
+	c.interval = c.interval[:0]
+}
+
+// replyToClockSync answers a clock exchange of the client, received
+// is when the signal was read from the websocket.
+func replyToClockSync(c *wsConn, signal *shared.WebsocketSignal, received time.Time) {
+	if signal.Clock == nil {
+		return
+	}
+	msg := new(shared.WebsocketSignal)
+	msg.SType = shared.ClockSync
+	msg.Clock = &shared.ClockSample{
+		T1: signal.Clock.T1,
+		T2: received.UnixNano(),
+		T3: time.Now().UnixNano(),
+	}
+	_ = c.WriteJSON(msg)
+}
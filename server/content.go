@@ -0,0 +1,62 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	// download payloads repeat a block of this size
+	contentBlockSize = 1 << 20
+	// blocks of more content modes than this are not kept
+	maxContentBlocks = 16
+)
+
+var (
+	contentBlocks     = make(map[shared.PayloadContent][]byte)
+	contentBlocksLock sync.Mutex
+)
+
+// contentReader returns an endless reader of the payload content the peer
+// asked for, random blocks are generated once and shared by all downloads.
+func contentReader(c *fiber.Ctx) (io.Reader, error) {
+	content, err := shared.ParsePayloadContent(c.Get(shared.ContentHeader))
+	if err != nil {
+		return nil, err
+	}
+	if content == shared.ContentZero {
+		return zeroReader{}, nil
+	}
+
+	contentBlocksLock.Lock()
+	defer contentBlocksLock.Unlock()
+	block, ok := contentBlocks[content]
+	if !ok {
+		block = make([]byte, contentBlockSize)
+		content.Fill(block)
+		if len(contentBlocks) < maxContentBlocks {
+			contentBlocks[content] = block
+		}
+	}
+	return shared.NewBlockReader(block), nil
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/hperf/shared"
+	"github.com/shirou/gopsutil/process"
+)
+
+const (
+	procStat = "/proc/stat"
+
+	// a core, or the hperf process across all cores, busier
+	// than this is reported as saturated.
+	cpuSaturationPercent = 90
+)
+
+// hperfProcess is opened once, samples are read concurrently by every test
+var hperfProcess = sync.OnceValues(func() (*process.Process, error) {
+	return process.NewProcess(int32(os.Getpid()))
+})
+
+// cpuTimes are the cumulative ticks of one cpu line in /proc/stat
+type cpuTimes struct {
+	user    uint64
+	nice    uint64
+	system  uint64
+	idle    uint64
+	iowait  uint64
+	irq     uint64
+	softirq uint64
+	steal   uint64
+}
+
+func (c cpuTimes) total() uint64 {
+	return c.user + c.nice + c.system + c.idle + c.iowait + c.irq + c.softirq + c.steal
+}
+
+type cpuSample struct {
+	created time.Time
+	total   cpuTimes
+	cores   map[int]cpuTimes
+	// user and system time of the hperf process
+	process time.Duration
+	rss     uint64
+}
+
+// parseProcStat reads the cpu lines of a file in the /proc/stat format:
+//
+//	cpu  71826 0 11507 246911 335 0 547 5953 0 0
+//	cpu0 71826 0 11507 246911 335 0 547 5953 0 0
+func parseProcStat(path string) (total cpuTimes, cores map[int]cpuTimes, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return total, nil, err
+	}
+	defer f.Close()
+
+	cores = make(map[int]cpuTimes)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		if len(fields) < 9 {
+			return total, nil, fmt.Errorf("Invalid line for %s in %s", fields[0], path)
+		}
+		values := make([]uint64, 8)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return total, nil, fmt.Errorf("Invalid value for %s in %s: %s", fields[0], path, err)
+			}
+		}
+		t := cpuTimes{
+			user:    values[0],
+			nice:    values[1],
+			system:  values[2],
+			idle:    values[3],
+			iowait:  values[4],
+			irq:     values[5],
+			softirq: values[6],
+			steal:   values[7],
+		}
+		if fields[0] == "cpu" {
+			total = t
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			return total, nil, fmt.Errorf("Invalid cpu (%s) in %s", fields[0], path)
+		}
+		cores[id] = t
+	}
+	return total, cores, sc.Err()
+}
+
+// readCPUSample reads the system CPU times (linux only)
+// and the CPU time and memory of the hperf process.
+func readCPUSample() (s *cpuSample, err error) {
+	s = &cpuSample{created: time.Now()}
+	if runtime.GOOS == "linux" {
+		s.total, s.cores, err = parseProcStat(procStat)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := hperfProcess()
+	if err != nil {
+		return nil, err
+	}
+	times, err := p.Times()
+	if err != nil {
+		return nil, err
+	}
+	s.process = time.Duration((times.User + times.System) * float64(time.Second))
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return nil, err
+	}
+	s.rss = mem.RSS
+	return s, nil
+}
+
+func tickPercent(ticks uint64, total uint64) int {
+	if total == 0 {
+		return 0
+	}
+	return int(ticks * 100 / total)
+}
+
+func cpuTimesDelta(prev cpuTimes, cur cpuTimes) (d cpuTimes) {
+	d.user = counterDelta(prev.user, cur.user)
+	d.nice = counterDelta(prev.nice, cur.nice)
+	d.system = counterDelta(prev.system, cur.system)
+	d.idle = counterDelta(prev.idle, cur.idle)
+	d.iowait = counterDelta(prev.iowait, cur.iowait)
+	d.irq = counterDelta(prev.irq, cur.irq)
+	d.softirq = counterDelta(prev.softirq, cur.softirq)
+	d.steal = counterDelta(prev.steal, cur.steal)
+	return
+}
+
+// coreStats returns the usage of every core between two samples. Busy is
+// everything but idle and iowait, a core which spends most of the interval
+// in softirq is usually handling the NIC interrupts.
+func coreStats(prev *cpuSample, cur *cpuSample) (cores []shared.CoreStats) {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	for _, id := range slices.Sorted(maps.Keys(cur.cores)) {
+		p, ok := prev.cores[id]
+		if !ok {
+			continue
+		}
+		delta := cpuTimesDelta(p, cur.cores[id])
+		sum := delta.total()
+		cores = append(cores, shared.CoreStats{
+			Core:    id,
+			User:    tickPercent(delta.user+delta.nice, sum),
+			System:  tickPercent(delta.system+delta.irq, sum),
+			SoftIRQ: tickPercent(delta.softirq, sum),
+			IOWait:  tickPercent(delta.iowait, sum),
+			Busy:    tickPercent(sum-delta.idle-delta.iowait, sum),
+		})
+	}
+	return
+}
+
+// addCPUStats adds the CPU usage between two samples to the data point,
+// cores is the usage of every core during the same interval.
+func addCPUStats(prev *cpuSample, cur *cpuSample, cores []shared.CoreStats, d *shared.DP) {
+	if prev == nil || cur == nil {
+		return
+	}
+	d.ProcessRSS = cur.rss
+	// the process time is spread over all cores, it is reported on the
+	// scale of the host values and saturates at the cores it may use.
+	if elapsed := cur.created.Sub(prev.created); elapsed > 0 {
+		cpus := runtime.NumCPU()
+		busy := float64(max(cur.process-prev.process, 0)) * 100 / float64(elapsed) / float64(cpus)
+		d.ProcessCPU = int(busy)
+		usable := float64(min(runtime.GOMAXPROCS(0), cpus)) / float64(cpus)
+		if busy >= cpuSaturationPercent*usable {
+			d.CPUSaturated = true
+		}
+	}
+
+	total := cpuTimesDelta(prev.total, cur.total)
+	d.CPUUser = tickPercent(total.user+total.nice, total.total())
+	d.CPUSystem = tickPercent(total.system+total.irq, total.total())
+	d.CPUSoftIRQ = tickPercent(total.softirq, total.total())
+	d.CPUIOWait = tickPercent(total.iowait, total.total())
+
+	d.CPUCoreHighID = -1
+	for _, core := range cores {
+		if d.CPUCoreHighID == -1 || core.Busy > d.CPUCoreHigh {
+			d.CPUCoreHigh = core.Busy
+			d.CPUCoreHighID = core.Core
+		}
+		if core.Busy >= cpuSaturationPercent {
+			d.CPUSaturated = true
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+func writeFixture(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseProcStat(t *testing.T) {
+	path := writeFixture(t, "stat", `cpu  71826 10 11507 246911 335 20 547 5953 0 0
+cpu0 40000 10 6000 120000 300 20 500 3000 0 0
+cpu1 31826 0 5507 126911 35 0 47 2953 0 0
+intr 1234 0 0
+ctxt 5678
+`)
+	total, cores, err := parseProcStat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := cpuTimes{user: 71826, nice: 10, system: 11507, idle: 246911, iowait: 335, irq: 20, softirq: 547, steal: 5953}
+	if total != expected {
+		t.Errorf("total: got %+v, expected %+v", total, expected)
+	}
+	if len(cores) != 2 {
+		t.Fatalf("expected 2 cores, got %d", len(cores))
+	}
+	if cores[1].steal != 2953 || cores[0].iowait != 300 || cores[0].softirq != 500 {
+		t.Errorf("unexpected core values: %+v", cores)
+	}
+
+	_, _, err = parseProcStat(writeFixture(t, "short", "cpu  1 2 3\n"))
+	if err == nil {
+		t.Error("expected an error for a short cpu line")
+	}
+	_, _, err = parseProcStat(writeFixture(t, "invalid", "cpu  1 2 3 4 5 6 7 x 0 0\n"))
+	if err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestAddCPUStatsDeltas(t *testing.T) {
+	// every counter moves by 1000 ticks in total, split per field
+	prevPath := writeFixture(t, "prev", `cpu  1000 0 1000 1000 1000 0 1000 1000 0 0
+cpu0 500 0 500 500 500 0 500 500 0 0
+cpu1 500 0 500 500 500 0 500 500 0 0
+`)
+	curPath := writeFixture(t, "cur", `cpu  1100 0 1100 1300 1200 0 1150 1150 0 0
+cpu0 500 0 500 500 500 0 1000 500 0 0
+cpu1 600 0 600 800 700 0 500 800 0 0
+`)
+	created := time.Now()
+	prev := &cpuSample{created: created}
+	cur := &cpuSample{created: created.Add(time.Second), rss: 1 << 20}
+	var err error
+	prev.total, prev.cores, err = parseProcStat(prevPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur.total, cur.cores, err = parseProcStat(curPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cores := coreStats(prev, cur)
+	d := new(shared.DP)
+	addCPUStats(prev, cur, cores, d)
+
+	if d.CPUUser != 10 || d.CPUSystem != 10 || d.CPUSoftIRQ != 15 || d.CPUIOWait != 20 {
+		t.Errorf("unexpected host values: user %d system %d softirq %d iowait %d",
+			d.CPUUser, d.CPUSystem, d.CPUSoftIRQ, d.CPUIOWait)
+	}
+	if len(cores) != 2 {
+		t.Fatalf("expected 2 cores, got %d", len(cores))
+	}
+	// cpu0 only handled softirqs during the interval
+	if cores[0].SoftIRQ != 100 || cores[0].Busy != 100 {
+		t.Errorf("unexpected cpu0 values: %+v", cores[0])
+	}
+	// steal counts as busy, idle and iowait do not
+	if cores[1].IOWait != 20 || cores[1].Busy != 50 {
+		t.Errorf("unexpected cpu1 values: %+v", cores[1])
+	}
+	if d.CPUCoreHighID != 0 || d.CPUCoreHigh != 100 || !d.CPUSaturated {
+		t.Errorf("expected cpu0 to be the saturated core, got cpu%d at %d%%", d.CPUCoreHighID, d.CPUCoreHigh)
+	}
+	if d.ProcessRSS != 1<<20 {
+		t.Errorf("unexpected rss %d", d.ProcessRSS)
+	}
+}
+
+func TestAddCPUStatsCounterReset(t *testing.T) {
+	prev := &cpuSample{total: cpuTimes{user: 5000, steal: 5000, idle: 5000}}
+	cur := &cpuSample{total: cpuTimes{user: 100, steal: 100, idle: 200}}
+	d := new(shared.DP)
+	addCPUStats(prev, cur, coreStats(prev, cur), d)
+	if d.CPUUser < 0 || d.CPUUser > 100 {
+		t.Errorf("invalid user value after a counter reset: %d", d.CPUUser)
+	}
+}
+
+func TestAddCPUStatsProcess(t *testing.T) {
+	created := time.Now()
+	cpus := runtime.NumCPU()
+	usable := min(runtime.GOMAXPROCS(0), cpus)
+
+	// busy on every core hperf may use
+	prev := &cpuSample{created: created}
+	cur := &cpuSample{created: created.Add(time.Second), process: time.Duration(usable) * time.Second}
+	d := new(shared.DP)
+	addCPUStats(prev, cur, coreStats(prev, cur), d)
+	if expected := usable * 100 / cpus; d.ProcessCPU < expected-1 || d.ProcessCPU > expected {
+		t.Errorf("expected hperf at %d%% of the host, got %d%%", expected, d.ProcessCPU)
+	}
+	if !d.CPUSaturated {
+		t.Error("expected the busy process to be saturated")
+	}
+
+	// a single core of a larger host is not saturated
+	if usable > 1 {
+		cur.process = time.Second
+		d = new(shared.DP)
+		addCPUStats(prev, cur, coreStats(prev, cur), d)
+		if d.CPUSaturated {
+			t.Errorf("process at %d%% should not be saturated", d.ProcessCPU)
+		}
+	}
+}
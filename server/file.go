@@ -19,46 +19,235 @@ package server
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/gofiber/contrib/websocket"
+	"github.com/klauspost/compress/zstd"
 	"github.com/minio/hperf/shared"
 )
 
-func streamTestFilesToWebsocket(con *websocket.Conn, testID string) (err error) {
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+var (
+	// rotation is disabled when both values are zero
+	rotateSize     int64
+	rotateInterval time.Duration
+	compression    = CompressNone
+
+	// tracks background compression of closed segments
+	compressions sync.WaitGroup
+	// the pending compressions of every test ID
+	pendingCompressions     = make(map[string]*pendingCompression)
+	pendingCompressionsLock sync.Mutex
+
+	compressionExtensions = map[string]string{
+		CompressGzip: ".gz",
+		CompressZstd: ".zst",
+	}
+)
+
+func validateCompression(c string) error {
+	switch c {
+	case "", CompressNone, CompressGzip, CompressZstd:
+		return nil
+	default:
+		return fmt.Errorf("Unknown compression (%s), expected one of: %s, %s, %s", c, CompressNone, CompressGzip, CompressZstd)
+	}
+}
+
+// metadata records are padded by this many bytes so they can be
+// updated in place when the segment is closed.
+const metadataSlack = 128
+
+type pendingCompression struct {
+	wg    sync.WaitGroup
+	count int
+}
+
+// compressInBackground compresses a closed segment of a test
+func compressInBackground(testID string, path string) {
+	pendingCompressionsLock.Lock()
+	p, ok := pendingCompressions[testID]
+	if !ok {
+		p = new(pendingCompression)
+		pendingCompressions[testID] = p
+	}
+	p.count++
+	p.wg.Add(1)
+	compressions.Add(1)
+	pendingCompressionsLock.Unlock()
+
+	go func() {
+		defer compressions.Done()
+		defer func() {
+			pendingCompressionsLock.Lock()
+			p.count--
+			if p.count == 0 {
+				delete(pendingCompressions, testID)
+			}
+			pendingCompressionsLock.Unlock()
+			p.wg.Done()
+		}()
+		// this can finish after the test ended and its errors were sent
+		err := compressSegment(path, compression)
+		if err != nil {
+			log.Println("Unable to compress", path+":", err)
+		}
+	}()
+}
+
+// waitForCompressions waits until the segments of earlier runs
+// of a test are compressed.
+func waitForCompressions(testID string) {
+	pendingCompressionsLock.Lock()
+	p, ok := pendingCompressions[testID]
+	pendingCompressionsLock.Unlock()
+	if ok {
+		p.wg.Wait()
+	}
+}
+
+type segment struct {
+	path  string
+	index int
+}
+
+// listSegments returns all segments for a test ordered by index. If a
+// segment exists both compressed and uncompressed, because compression
+// is still in progress, the uncompressed file is used.
+func listSegments(testID string) (segments []segment, err error) {
 	var files []string
 	files, err = filepath.Glob(filepath.Join(basePath, testID+".*"))
 	if err != nil {
 		return
 	}
-	msg := new(shared.WebsocketSignal)
+
+	byIndex := make(map[int]string)
 	for _, path := range files {
-		f, err := os.Open(path)
+		name := strings.TrimPrefix(filepath.Base(path), testID+".")
+		compressed := false
+		for _, ext := range compressionExtensions {
+			if strings.HasSuffix(name, ext) {
+				name = strings.TrimSuffix(name, ext)
+				compressed = true
+			}
+		}
+		index, err := strconv.Atoi(name)
 		if err != nil {
-			return err
+			continue
 		}
-		s := bufio.NewScanner(f)
-		for s.Scan() {
-			msg.Data = s.Bytes()
-			msg.SType = shared.GetTest
-			msg.Code = 200
-			err = con.WriteJSON(msg)
-			if err != nil {
-				return err
-			}
+		_, ok := byIndex[index]
+		if ok && compressed {
+			continue
 		}
-		if s.Err() != nil {
-			return s.Err()
+		byIndex[index] = path
+	}
+
+	segments = make([]segment, 0, len(byIndex))
+	for index, path := range byIndex {
+		segments = append(segments, segment{path: path, index: index})
+	}
+	slices.SortFunc(segments, func(a, b segment) int {
+		return a.index - b.index
+	})
+	return
+}
+
+// openSegment opens a segment and decompresses it if needed
+func openSegment(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filepath.Ext(path) {
+	case compressionExtensions[CompressGzip]:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &segmentReader{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case compressionExtensions[CompressZstd]:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &segmentReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), f}}, nil
+	default:
+		return f, nil
+	}
+}
+
+type segmentReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s *segmentReader) Close() (err error) {
+	for _, c := range s.closers {
+		cerr := c.Close()
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+func streamTestFilesToWebsocket(con *wsConn, testID string) (err error) {
+	var segments []segment
+	segments, err = listSegments(testID)
+	if err != nil {
+		return
+	}
+	msg := new(shared.WebsocketSignal)
+	for _, seg := range segments {
+		err = streamSegmentToWebsocket(con, msg, seg.path)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func deleteTestsFromDisk(con *websocket.Conn, signal shared.WebsocketSignal) (err error) {
+func streamSegmentToWebsocket(con *wsConn, msg *shared.WebsocketSignal, path string) error {
+	f, err := openSegment(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		msg.Data = s.Bytes()
+		msg.SType = shared.GetTest
+		msg.Code = 200
+		err = con.WriteJSON(msg)
+		if err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func deleteTestsFromDisk(con *wsConn, signal shared.WebsocketSignal) (err error) {
 	defer SendDone(con)
 
 	if signal.Config.TestID == "" {
@@ -69,7 +258,7 @@ func deleteTestsFromDisk(con *websocket.Conn, signal shared.WebsocketSignal) (er
 	}
 
 	var files []string
-	files, err = filepath.Glob(filepath.Join(basePath, signal.Config.TestID+".*"))
+	files, err = testFiles(signal.Config.TestID)
 	if err != nil {
 		SendError(con, err)
 		return
@@ -87,11 +276,16 @@ func deleteTestsFromDisk(con *websocket.Conn, signal shared.WebsocketSignal) (er
 
 func listTestsFromDisk() (finalList []shared.TestInfo, err error) {
 	var files []string
-	files, err = filepath.Glob(filepath.Join(basePath, "*.1"))
-	if err != nil {
-		return
+	for _, ext := range []string{"", compressionExtensions[CompressGzip], compressionExtensions[CompressZstd]} {
+		var matches []string
+		matches, err = filepath.Glob(filepath.Join(basePath, "*.1"+ext))
+		if err != nil {
+			return
+		}
+		files = append(files, matches...)
 	}
 
+	seen := make(map[string]struct{})
 	finalList = make([]shared.TestInfo, 0)
 	for _, path := range files {
 		var stat os.FileInfo
@@ -99,19 +293,46 @@ func listTestsFromDisk() (finalList []shared.TestInfo, err error) {
 		if err != nil {
 			return
 		}
-		trimPath := strings.TrimSuffix(path, ".1")
+		trimPath := path
+		for _, ext := range compressionExtensions {
+			trimPath = strings.TrimSuffix(trimPath, ext)
+		}
+		trimPath = strings.TrimSuffix(trimPath, ".1")
 		finalPath := strings.Split(trimPath, string(os.PathSeparator))
+		id := finalPath[len(finalPath)-1]
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
 		finalList = append(finalList, shared.TestInfo{
-			ID:   finalPath[len(finalPath)-1],
+			ID:   id,
 			Time: stat.ModTime(),
 		})
 	}
 	return
 }
 
+// testFiles lists the segments of a test including the hidden
+// temporary files of segments which are being compressed.
+func testFiles(testID string) (files []string, err error) {
+	files, err = filepath.Glob(filepath.Join(basePath, testID+".*"))
+	if err != nil {
+		return
+	}
+	var tmp []string
+	tmp, err = filepath.Glob(filepath.Join(basePath, "."+testID+".*.tmp"))
+	if err != nil {
+		return
+	}
+	return append(files, tmp...), nil
+}
+
 func resetTestFiles(t *test) (err error) {
+	// a compression finishing later would bring back an old segment
+	waitForCompressions(t.ID)
+
 	var files []string
-	files, err = filepath.Glob(filepath.Join(basePath, t.ID+"*"))
+	files, err = testFiles(t.ID)
 	if err != nil {
 		return
 	}
@@ -127,7 +348,7 @@ func resetTestFiles(t *test) (err error) {
 
 func newTestFile(t *test) (f *os.File, err error) {
 	if t.DataFile != nil {
-		t.DataFile.Close()
+		finishTestFile(t)
 	}
 
 	err = os.MkdirAll(basePath, 0o777)
@@ -139,6 +360,182 @@ func newTestFile(t *test) (f *os.File, err error) {
 	if err != nil {
 		return
 	}
+	t.DataFileCreated = time.Now()
+
+	b, err := metadataRecord(t, 0)
+	if err == nil {
+		t.DataFileMetaSize = len(b)
+		_, err = t.DataFile.Write(b)
+	}
+	if err != nil {
+		t.AddError(err, "file-metadata")
+	}
 
 	return
 }
+
+// metadataRecord returns the metadata record of a test padded to size,
+// or padded by metadataSlack when size is 0. It fails when the record
+// does not fit into size.
+func metadataRecord(t *test, size int) ([]byte, error) {
+	m, err := json.Marshal(t.Metadata())
+	if err != nil {
+		return nil, err
+	}
+	b := append(shared.MetadataPoint.String(), m...)
+	if size == 0 {
+		size = len(b) + metadataSlack + 1
+	}
+	if len(b)+1 > size {
+		return nil, fmt.Errorf("Metadata record of %d bytes does not fit into %d bytes", len(b)+1, size)
+	}
+	b = append(b, bytes.Repeat([]byte{' '}, size-len(b)-1)...)
+	return append(b, '\n'), nil
+}
+
+// rotateTestFile starts a new segment when the current
+// one has reached the configured size or age.
+func rotateTestFile(t *test) {
+	if t.DataFile == nil {
+		return
+	}
+
+	rotate := false
+	if rotateInterval > 0 && time.Since(t.DataFileCreated) >= rotateInterval {
+		rotate = true
+	}
+	if rotateSize > 0 {
+		stat, err := t.DataFile.Stat()
+		if err != nil {
+			t.AddError(err, "file-stat")
+		} else if stat.Size() >= rotateSize {
+			rotate = true
+		}
+	}
+
+	if !rotate {
+		return
+	}
+
+	_, err := newTestFile(t)
+	if err != nil {
+		t.AddError(err, "file-rotate")
+	}
+}
+
+// finishTestFile closes the current segment and compresses it in the background
+func finishTestFile(t *test) {
+	if t.DataFile == nil {
+		return
+	}
+	path := t.DataFile.Name()
+	closeTestFile(t)
+
+	if compression == CompressNone || compression == "" {
+		return
+	}
+	compressInBackground(t.ID, path)
+}
+
+// discardTestFile closes and removes the current file of a test which failed to start
+func discardTestFile(t *test) {
+	if t.DataFile == nil {
+		return
+	}
+	path := t.DataFile.Name()
+	t.DataFile.Close()
+	t.DataFile = nil
+	os.Remove(path)
+}
+
+func closeTestFile(t *test) {
+	if t.DataFile == nil {
+		return
+	}
+	// the record at the start of the segment gets the end of the test,
+	// a second record is only appended when it does not fit.
+	var err error
+	var b []byte
+	if t.DataFileMetaSize > 0 {
+		b, err = metadataRecord(t, t.DataFileMetaSize)
+	}
+	if t.DataFileMetaSize > 0 && err == nil {
+		_, err = t.DataFile.WriteAt(b, 0)
+	} else {
+		_, err = shared.WriteStructAndNewLineToFile(t.DataFile, shared.MetadataPoint, t.Metadata())
+	}
+	if err != nil {
+		t.AddError(err, "file-metadata")
+	}
+	err = t.DataFile.Sync()
+	if err != nil {
+		t.AddError(err, "file-sync")
+	}
+	err = t.DataFile.Close()
+	if err != nil {
+		t.AddError(err, "file-close")
+	}
+	t.DataFile = nil
+}
+
+// compressSegment writes a compressed copy of the segment next to it and
+// removes the original. The temporary file is hidden so it is never
+// picked up while it is being written.
+func compressSegment(path string, method string) (err error) {
+	ext, ok := compressionExtensions[method]
+	if !ok {
+		return fmt.Errorf("Unknown compression: %s", method)
+	}
+	tmpPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+ext+".tmp")
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	var w io.WriteCloser
+	switch method {
+	case CompressGzip:
+		w = gzip.NewWriter(out)
+	case CompressZstd:
+		w, err = zstd.NewWriter(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.Copy(w, in)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	err = out.Sync()
+	if err != nil {
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path+ext)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
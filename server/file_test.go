@@ -0,0 +1,97 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+func TestMetadataUpdatedInPlace(t *testing.T) {
+	oldBase, oldCompression := basePath, compression
+	defer func() { basePath, compression = oldBase, oldCompression }()
+	basePath = t.TempDir() + string(os.PathSeparator)
+	compression = CompressNone
+
+	tst := &test{ID: "meta", errMap: make(map[string]struct{})}
+	_, err := newTestFile(tst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = shared.WriteStructAndNewLineToFile(tst.DataFile, shared.DataPoint, shared.DP{TestID: tst.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tst.ended = time.Now()
+	finishTestFile(tst)
+
+	b, err := os.ReadFile(filepath.Join(basePath, "meta.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte{'\n'})
+	if len(lines) != 2 {
+		t.Fatalf("expected a metadata and a data record, got %d lines", len(lines))
+	}
+	if !bytes.HasPrefix(lines[0], shared.MetadataPoint.String()) || !bytes.HasPrefix(lines[1], shared.DataPoint.String()) {
+		t.Fatalf("unexpected records: %q", b)
+	}
+	var m shared.TestMetadata
+	err = json.Unmarshal(lines[0][1:], &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Ended.IsZero() {
+		t.Error("expected the metadata to be updated with the end of the test")
+	}
+	if len(tst.errors) > 0 {
+		t.Errorf("unexpected errors: %+v", tst.errors)
+	}
+}
+
+func TestResetWaitsForCompression(t *testing.T) {
+	oldBase, oldCompression := basePath, compression
+	defer func() { basePath, compression = oldBase, oldCompression }()
+	basePath = t.TempDir() + string(os.PathSeparator)
+	compression = CompressGzip
+
+	tst := &test{ID: "reset", errMap: make(map[string]struct{})}
+	_, err := newTestFile(tst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finishTestFile(tst)
+
+	err = resetTestFiles(tst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := testFiles(tst.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files after the reset, got %v", files)
+	}
+}
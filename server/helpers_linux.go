@@ -21,27 +21,24 @@
 package server
 
 import (
+	"fmt"
+	"net"
 	"syscall"
 
+	"github.com/minio/hperf/shared"
 	"golang.org/x/sys/unix"
 )
 
-func setTCPParametersFn() func(network, address string, c syscall.RawConn) error {
+func setTCPParametersFn(o shared.SocketOptions) func(network, address string, c syscall.RawConn) error {
 	return func(network, address string, c syscall.RawConn) error {
-		c.Control(func(fdPtr uintptr) {
+		var err error
+		cerr := c.Control(func(fdPtr uintptr) {
 			// got socket file descriptor to set parameters.
 			fd := int(fdPtr)
 
 			_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
 			_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
 
-			{
-				// Enable big buffers
-				// _ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF, 65535)
-				// _ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, 65535)
-			}
-			_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.TCP_NODELAY, 1)
-
 			// Enable TCP open
 			// https://lwn.net/Articles/508865/ - 32k queue size.
 			_ = syscall.SetsockoptInt(fd, syscall.SOL_TCP, unix.TCP_FASTOPEN, 32*1024)
@@ -72,7 +69,195 @@ func setTCPParametersFn() func(network, address string, c syscall.RawConn) error
 				// ~ cat /proc/sys/net/ipv4/tcp_keepalive_intvl (defaults to 75 secs, we reduce it to 15 secs)
 				_ = syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, 15)
 			}
+
+			// Buffers and the segment size have to be set before
+			// connecting to be used for the handshake.
+			err = setSocketOptions(fd, o, network == "tcp6")
 		})
-		return nil
+		if cerr != nil {
+			return cerr
+		}
+		return err
+	}
+}
+
+// setSocketOptions applies the socket options of a test, TCP_NODELAY is left
+// alone because Go sets it once the connection is established.
+func setSocketOptions(fd int, o shared.SocketOptions, ipv6 bool) error {
+	if o.SendBuffer > 0 {
+		err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF, o.SendBuffer)
+		if err != nil {
+			return fmt.Errorf("SO_SNDBUF %d: %w", o.SendBuffer, err)
+		}
+	}
+	if o.ReceiveBuffer > 0 {
+		err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, o.ReceiveBuffer)
+		if err != nil {
+			return fmt.Errorf("SO_RCVBUF %d: %w", o.ReceiveBuffer, err)
+		}
+	}
+	if o.Congestion != "" {
+		err := unix.SetsockoptString(fd, unix.IPPROTO_TCP, unix.TCP_CONGESTION, o.Congestion)
+		if err != nil {
+			return fmt.Errorf("TCP_CONGESTION %s: %w", o.Congestion, err)
+		}
+	}
+	if o.MaxSegment > 0 {
+		err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_MAXSEG, o.MaxSegment)
+		if err != nil {
+			return fmt.Errorf("TCP_MAXSEG %d: %w", o.MaxSegment, err)
+		}
+	}
+	if o.TOS > 0 {
+		var err error
+		if ipv6 {
+			err = unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_TCLASS, o.TOS)
+		} else {
+			err = unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_TOS, o.TOS)
+		}
+		if err != nil {
+			return fmt.Errorf("TOS %d: %w", o.TOS, err)
+		}
+	}
+	return nil
+}
+
+// checkSocketOptions tries the options on a new socket, a test fails
+// right away instead of on every dial when the kernel rejects them.
+func checkSocketOptions(o shared.SocketOptions) error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	err = setSocketOptions(fd, o, false)
+	if err != nil {
+		return fmt.Errorf("Socket options were rejected: %w", err)
+	}
+	return nil
+}
+
+// applySocketOptions sets the socket options of a test on an accepted connection
+func applySocketOptions(con net.Conn, o shared.SocketOptions) error {
+	raw, err := rawConn(con)
+	if err != nil {
+		return err
+	}
+	ipv6 := isIPv6Conn(con)
+	cerr := raw.Control(func(fd uintptr) {
+		err = setSocketOptions(int(fd), o, ipv6)
+		if err == nil && o.Nagle {
+			err = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_NODELAY, 0)
+		}
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// readSocketState reads back the socket options the kernel applied
+func readSocketState(con net.Conn) (s shared.SocketState, ok bool) {
+	raw, err := rawConn(con)
+	if err != nil {
+		return
+	}
+	ipv6 := isIPv6Conn(con)
+	cerr := raw.Control(func(fdPtr uintptr) {
+		fd := int(fdPtr)
+		var noDelay int
+		s.SendBuffer, err = unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF)
+		if err != nil {
+			return
+		}
+		s.ReceiveBuffer, err = unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF)
+		if err != nil {
+			return
+		}
+		s.Congestion, err = unix.GetsockoptString(fd, unix.IPPROTO_TCP, unix.TCP_CONGESTION)
+		if err != nil {
+			return
+		}
+		s.MaxSegment, err = unix.GetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_MAXSEG)
+		if err != nil {
+			return
+		}
+		noDelay, err = unix.GetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_NODELAY)
+		if err != nil {
+			return
+		}
+		s.NoDelay = noDelay != 0
+		if ipv6 {
+			s.TOS, err = unix.GetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_TCLASS)
+		} else {
+			s.TOS, err = unix.GetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_TOS)
+		}
+	})
+	return s, cerr == nil && err == nil
+}
+
+func readTCPInfo(con net.Conn) (s tcpSample, ok bool) {
+	sc, isSyscallConn := con.(syscall.Conn)
+	if !isSyscallConn {
+		return
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return
+	}
+	var info *unix.TCPInfo
+	err = raw.Control(func(fd uintptr) {
+		info, err = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil || info == nil {
+		return
+	}
+	return tcpSample{
+		RTT:          info.Rtt,
+		RTTVar:       info.Rttvar,
+		TotalRetrans: info.Total_retrans,
+		Lost:         info.Lost,
+		Cwnd:         info.Snd_cwnd,
+		DeliveryRate: info.Delivery_rate,
+		PacingRate:   info.Pacing_rate,
+	}, true
+}
+
+// setDontFragmentFn sets the don't fragment bit on every datagram of the socket,
+// datagrams larger than the path MTU known to the kernel fail with EMSGSIZE.
+func setDontFragmentFn() func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var err error
+		cerr := c.Control(func(fd uintptr) {
+			if network == "udp6" {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, unix.IPV6_PMTUDISC_DO)
+				return
+			}
+			err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO)
+		})
+		if cerr != nil {
+			return cerr
+		}
+		return err
+	}
+}
+
+// readPathMTU returns the path MTU the kernel knows for the peer of a connected socket
+func readPathMTU(con *net.UDPConn, ipv6 bool) int {
+	raw, err := con.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	var mtu int
+	err = raw.Control(func(fd uintptr) {
+		if ipv6 {
+			mtu, err = unix.GetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU)
+			return
+		}
+		mtu, err = unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU)
+	})
+	if err != nil {
+		return 0
 	}
+	return mtu
 }
@@ -20,11 +20,51 @@
 
 package server
 
-import "syscall"
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/minio/hperf/shared"
+)
 
 //nolint:unused
-func setTCPParametersFn() func(network, address string, c syscall.RawConn) error {
+func setTCPParametersFn(o shared.SocketOptions) func(network, address string, c syscall.RawConn) error {
 	return func(network, address string, c syscall.RawConn) error {
+		if o.IsSet() {
+			return errSocketOptions
+		}
 		return nil
 	}
 }
+
+// socket options are only applied on linux
+var errSocketOptions = errors.New("Socket options are only supported on linux")
+
+func checkSocketOptions(_ shared.SocketOptions) error {
+	return errSocketOptions
+}
+
+func applySocketOptions(_ net.Conn, _ shared.SocketOptions) error {
+	return errSocketOptions
+}
+
+func readSocketState(_ net.Conn) (s shared.SocketState, ok bool) {
+	return
+}
+
+// TCP_INFO is only sampled on linux
+func readTCPInfo(_ net.Conn) (s tcpSample, ok bool) {
+	return
+}
+
+// the don't fragment bit is only set on linux
+func setDontFragmentFn() func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		return errors.New("MTU probes are only supported on linux")
+	}
+}
+
+func readPathMTU(_ *net.UDPConn, _ bool) int {
+	return 0
+}
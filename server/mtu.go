@@ -0,0 +1,235 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	mtuProbeAttempts = 3
+	minProbeTimeout  = 50 * time.Millisecond
+	maxProbeTimeout  = time.Second
+	// paths are searched again during the test to catch changes
+	mtuProbeInterval = time.Second
+)
+
+// mtuProber finds the largest datagram which reaches the peer
+// with the don't fragment bit set.
+type mtuProber struct {
+	t        *test
+	r        *netPerfReader
+	con      *net.UDPConn
+	buf      []byte
+	ack      []byte
+	overhead int
+	sequence uint64
+	// smoothed round trip time of acknowledged probes
+	rtt time.Duration
+}
+
+func probeMTU(t *test, r *netPerfReader) {
+	defer func() {
+		rec := recover()
+		if rec != nil {
+			log.Println(rec, string(debug.Stack()))
+		}
+	}()
+
+	d := &net.Dialer{
+		LocalAddr: localUDPAddr(),
+		Timeout:   10 * time.Second,
+		Control:   setDontFragmentFn(),
+	}
+	con, err := d.DialContext(r.ctx, "udp", r.addr)
+	if err != nil {
+		t.AddError(fmt.Errorf("Unable to start MTU probes to %s: %s", r.addr, err), "mtu-dial")
+		return
+	}
+	defer con.Close()
+
+	local := con.LocalAddr().(*net.UDPAddr)
+	ipv6 := local.IP.To4() == nil
+	p := &mtuProber{
+		t:        t,
+		r:        r,
+		con:      con.(*net.UDPConn),
+		ack:      make([]byte, shared.MTUProbeHeaderSize(t.ID)),
+		overhead: shared.IPv4ProbeOverhead,
+	}
+	lowest := shared.MinIPv4MTU
+	if ipv6 {
+		p.overhead = shared.IPv6ProbeOverhead
+		lowest = shared.MinIPv6MTU
+	}
+
+	// the path can not be wider than the interface the probes leave from
+	interfaceMTU := 0
+	highest := shared.MaxDatagramSize + p.overhead
+	iface, err := interfaceForIP(local.IP)
+	if err == nil {
+		// loopback MTUs can be larger than an IP packet
+		highest = min(highest, iface.MTU)
+		interfaceMTU = highest
+	}
+	p.buf = make([]byte, highest-p.overhead)
+	lowest = max(min(lowest, highest), shared.MTUProbeHeaderSize(t.ID)+p.overhead)
+
+	for r.ctx.Err() == nil {
+		mtu := p.search(lowest, highest)
+
+		r.m.Lock()
+		r.pathMTU = mtu
+		r.interfaceMTU = interfaceMTU
+		r.kernelMTU = readPathMTU(p.con, ipv6)
+		r.hasStats = true
+		r.m.Unlock()
+
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(mtuProbeInterval):
+		}
+	}
+}
+
+// search returns the largest MTU between lowest and highest which
+// reaches the peer, or 0 when not even the lowest one does.
+func (p *mtuProber) search(lowest int, highest int) int {
+	if p.probe(highest) {
+		return highest
+	}
+	if !p.probe(lowest) {
+		return 0
+	}
+	for highest-lowest > 1 && p.r.ctx.Err() == nil {
+		mid := (lowest + highest) / 2
+		if p.probe(mid) {
+			lowest = mid
+		} else {
+			highest = mid
+		}
+	}
+	return lowest
+}
+
+// probe sends a datagram which fills a packet of mtu bytes and
+// waits for the peer to acknowledge it.
+func (p *mtuProber) probe(mtu int) bool {
+	size := mtu - p.overhead
+	for range mtuProbeAttempts {
+		if p.r.ctx.Err() != nil {
+			return false
+		}
+		p.sequence++
+		probe := shared.MTUProbe{TestID: p.t.ID, Sequence: p.sequence, Size: size}
+		_, err := probe.Encode(p.buf[:size], authKey)
+		if err != nil {
+			p.t.AddError(err, "mtu-encode")
+			return false
+		}
+
+		sent := time.Now()
+		_, err = p.con.Write(p.buf[:size])
+		if errors.Is(err, syscall.EMSGSIZE) {
+			// larger than the path MTU the kernel knows about
+			return false
+		}
+		if err != nil {
+			p.t.AddError(fmt.Errorf("Unable to send MTU probe to %s: %s", p.r.addr, err), "mtu-write-"+p.r.addr)
+			continue
+		}
+		p.r.TX.Add(uint64(size))
+		p.r.TXCount.Add(1)
+
+		if p.waitForAck(probe, sent) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *mtuProber) waitForAck(probe shared.MTUProbe, sent time.Time) bool {
+	timeout := maxProbeTimeout
+	if p.rtt > 0 {
+		timeout = min(max(4*p.rtt, minProbeTimeout), maxProbeTimeout)
+	}
+	p.con.SetReadDeadline(sent.Add(timeout))
+	for {
+		n, err := p.con.Read(p.ack)
+		if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, syscall.EMSGSIZE) {
+			// lost, or an ICMP fragmentation needed message lowered the path MTU
+			return false
+		}
+		if err != nil {
+			p.t.AddError(fmt.Errorf("No answer to MTU probes from %s: %s", p.r.addr, err), "mtu-read-"+p.r.addr)
+			// refused probes fail right away, wait before the next one
+			time.Sleep(time.Until(sent.Add(timeout)))
+			return false
+		}
+		ack, err := shared.DecodeMTUProbe(p.ack[:n], authKey)
+		if err != nil || !ack.Ack || ack.Sequence != probe.Sequence {
+			// acknowledgements of earlier probes
+			continue
+		}
+		rtt := time.Since(sent)
+		if p.rtt == 0 {
+			p.rtt = rtt
+		} else {
+			p.rtt += (rtt - p.rtt) / 8
+		}
+		return ack.Size == probe.Size
+	}
+}
+
+// answerMTUProbe acknowledges a probe of a test running on this server
+func answerMTUProbe(b []byte, from netip.AddrPort) {
+	probe, err := shared.DecodeMTUProbe(b, authKey)
+	if err != nil {
+		logRejected(from.String(), "udp", err)
+		return
+	}
+	t, ok := tests.Get(probe.TestID)
+	if probe.Ack || !ok || t.ctx.Err() != nil {
+		return
+	}
+	ack := shared.MTUProbe{
+		TestID:   probe.TestID,
+		Sequence: probe.Sequence,
+		Size:     len(b),
+		Ack:      true,
+	}
+	buf := make([]byte, shared.MTUProbeHeaderSize(ack.TestID))
+	_, err = ack.Encode(buf, authKey)
+	if err != nil {
+		return
+	}
+	_, err = udpConn.WriteToUDPAddrPort(buf, from)
+	if err != nil {
+		shared.DEBUG("Error acknowledging MTU probe:", err)
+	}
+}
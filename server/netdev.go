@@ -0,0 +1,227 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	procNetDev = "/proc/net/dev"
+
+	// InterfaceAuto selects the interface which owns the server address
+	InterfaceAuto = "auto"
+	// interfaces prefixed with InterfaceIgnore are never reported
+	InterfaceIgnore = "-"
+)
+
+// nicSelection are the interfaces reported in data points
+type nicSelection struct {
+	// all interfaces are reported when empty
+	include []string
+	ignore  []string
+}
+
+func (s nicSelection) monitored(name string) bool {
+	if slices.Contains(s.ignore, name) {
+		return false
+	}
+	return len(s.include) == 0 || slices.Contains(s.include, name)
+}
+
+var monitoredNICs nicSelection
+
+// parseNetDev reads the interface counters from a file in the /proc/net/dev format:
+//
+//	Inter-|   Receive                                                |  Transmit
+//	 face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+//	  eth0: 1024    8       0    0    0    0     0          0         2048     16      0    0    0    0     0       0
+func parseNetDev(path string) (map[string]shared.NICStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]shared.NICStats)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// large counters are not separated from the interface name
+		name, counters, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(counters)
+		if len(fields) < 13 {
+			return nil, fmt.Errorf("Invalid line for interface %s in %s", name, path)
+		}
+		values := make([]uint64, 13)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid counter for interface %s in %s: %s", name, path, err)
+			}
+		}
+		stats[name] = shared.NICStats{
+			Interface: name,
+			RXBytes:   values[0],
+			RXPackets: values[1],
+			RXErrors:  values[2],
+			RXDropped: values[3],
+			RXFifo:    values[4],
+			RXFrame:   values[5],
+			TXBytes:   values[8],
+			TXPackets: values[9],
+			TXErrors:  values[10],
+			TXDropped: values[11],
+			TXFifo:    values[12],
+		}
+	}
+	return stats, sc.Err()
+}
+
+func readNICCounters() (map[string]shared.NICStats, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+	return parseNetDev(procNetDev)
+}
+
+// nicDeltas returns the change of every monitored interface between two
+// samples and the number of packets dropped on them.
+func nicDeltas(prev map[string]shared.NICStats, cur map[string]shared.NICStats, sel nicSelection) (deltas []shared.NICStats, dropped int) {
+	for name, c := range cur {
+		if !sel.monitored(name) {
+			continue
+		}
+		p, ok := prev[name]
+		if !ok {
+			continue
+		}
+		d := shared.NICStats{
+			Interface: name,
+			RXBytes:   counterDelta(p.RXBytes, c.RXBytes),
+			RXPackets: counterDelta(p.RXPackets, c.RXPackets),
+			RXErrors:  counterDelta(p.RXErrors, c.RXErrors),
+			RXDropped: counterDelta(p.RXDropped, c.RXDropped),
+			RXFifo:    counterDelta(p.RXFifo, c.RXFifo),
+			RXFrame:   counterDelta(p.RXFrame, c.RXFrame),
+			TXBytes:   counterDelta(p.TXBytes, c.TXBytes),
+			TXPackets: counterDelta(p.TXPackets, c.TXPackets),
+			TXErrors:  counterDelta(p.TXErrors, c.TXErrors),
+			TXDropped: counterDelta(p.TXDropped, c.TXDropped),
+			TXFifo:    counterDelta(p.TXFifo, c.TXFifo),
+		}
+		dropped += int(d.RXDropped + d.TXDropped)
+		deltas = append(deltas, d)
+	}
+	slices.SortFunc(deltas, func(a, b shared.NICStats) int {
+		return strings.Compare(a.Interface, b.Interface)
+	})
+	return
+}
+
+// counterDelta treats a counter which went backwards as reset,
+// which happens when an interface is re-created.
+func counterDelta(prev uint64, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// resolveInterfaces parses a comma separated list of interface names,
+// InterfaceAuto is replaced by the interface owning the real IP or
+// the bind address. Names prefixed with InterfaceIgnore are ignored.
+func resolveInterfaces(list string) (sel nicSelection, err error) {
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		ignore := strings.HasPrefix(name, InterfaceIgnore)
+		name = strings.TrimPrefix(name, InterfaceIgnore)
+		if name == "" {
+			continue
+		}
+		if name == InterfaceAuto {
+			name, err = interfaceForAddress(localAddress())
+			if err != nil {
+				return sel, err
+			}
+		}
+		if ignore {
+			sel.ignore = append(sel.ignore, name)
+		} else {
+			sel.include = append(sel.include, name)
+		}
+	}
+	return
+}
+
+func interfaceForAddress(address string) (string, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsUnspecified() {
+		return "", fmt.Errorf("Unable to select the test interface for %s, use --real-ip or name the interface", address)
+	}
+	iface, err := interfaceForIP(ip)
+	if err != nil {
+		return "", err
+	}
+	return iface.Name, nil
+}
+
+// interfaceForIP returns the interface which owns ip
+func interfaceForIP(ip net.IP) (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			n, ok := a.(*net.IPNet)
+			if ok && n.IP.Equal(ip) {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	// the whole loopback range is routed to lo without being assigned
+	if ip.IsLoopback() {
+		for i := range ifaces {
+			if ifaces[i].Flags&net.FlagLoopback != 0 {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("No interface found for %s", ip)
+}
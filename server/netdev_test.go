@@ -0,0 +1,143 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/minio/hperf/shared"
+)
+
+const netDevHeader = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+`
+
+func TestParseNetDev(t *testing.T) {
+	path := writeFixture(t, "dev", netDevHeader+
+		`    lo:   1024       8    0    0    0     0          0         0     1024       8    0    0    0     0       0          0
+  eth0: 5000000    4000    1    2    3     4          0        10  6000000    5000    5    6    7     0       0          0
+eth1:18446744073709551615 1 0 0 0 0 0 0 12 1 0 0 0 0 0 0
+`)
+	stats, err := parseNetDev(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 interfaces, got %d", len(stats))
+	}
+	expected := shared.NICStats{
+		Interface: "eth0",
+		RXBytes:   5000000, RXPackets: 4000, RXErrors: 1, RXDropped: 2, RXFifo: 3, RXFrame: 4,
+		TXBytes: 6000000, TXPackets: 5000, TXErrors: 5, TXDropped: 6, TXFifo: 7,
+	}
+	if stats["eth0"] != expected {
+		t.Errorf("eth0: got %+v, expected %+v", stats["eth0"], expected)
+	}
+	// large counters are not separated from the interface name
+	if stats["eth1"].RXBytes != 18446744073709551615 || stats["eth1"].TXBytes != 12 {
+		t.Errorf("eth1: unexpected counters %+v", stats["eth1"])
+	}
+
+	_, err = parseNetDev(writeFixture(t, "short", netDevHeader+"  eth0: 1 2 3\n"))
+	if err == nil {
+		t.Error("expected an error for a short line")
+	}
+	_, err = parseNetDev(writeFixture(t, "invalid", netDevHeader+"  eth0: 1 2 3 4 5 6 7 8 x 10 11 12 13 14 15 16\n"))
+	if err == nil {
+		t.Error("expected an error for an invalid counter")
+	}
+}
+
+func netDevSample(t *testing.T, lines string) map[string]shared.NICStats {
+	stats, err := parseNetDev(writeFixture(t, "dev", netDevHeader+lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestNICDeltas(t *testing.T) {
+	prev := netDevSample(t, `    lo: 1000 10 0 0 0 0 0 0 1000 10 0 0 0 0 0 0
+  eth0: 1000 10 0 5 0 0 0 0 2000 20 0 1 0 0 0 0
+  eth1: 9000 90 0 9 0 0 0 0 9000 90 0 9 0 0 0 0
+`)
+	// eth1 was re-created and its counters started over, eth2 is new
+	cur := netDevSample(t, `    lo: 1500 15 0 0 0 0 0 0 1500 15 0 0 0 0 0 0
+  eth0: 3000 30 1 8 0 2 0 0 2500 25 0 3 1 0 0 0
+  eth1: 100 1 0 2 0 0 0 0 200 2 0 1 0 0 0 0
+  eth2: 100 1 0 50 0 0 0 0 100 1 0 50 0 0 0 0
+`)
+
+	deltas, dropped := nicDeltas(prev, cur, nicSelection{})
+	if len(deltas) != 3 || deltas[0].Interface != "eth0" || deltas[1].Interface != "eth1" || deltas[2].Interface != "lo" {
+		t.Fatalf("expected the sorted deltas of eth0, eth1 and lo, got %+v", deltas)
+	}
+	expected := shared.NICStats{
+		Interface: "eth0",
+		RXBytes:   2000, RXPackets: 20, RXErrors: 1, RXDropped: 3, RXFrame: 2,
+		TXBytes: 500, TXPackets: 5, TXDropped: 2, TXFifo: 1,
+	}
+	if deltas[0] != expected {
+		t.Errorf("eth0: got %+v, expected %+v", deltas[0], expected)
+	}
+	if deltas[1].RXBytes != 100 || deltas[1].RXDropped != 2 || deltas[1].TXDropped != 1 {
+		t.Errorf("eth1: a reset counter should count from zero, got %+v", deltas[1])
+	}
+	if dropped != 3+2+2+1 {
+		t.Errorf("expected 8 dropped packets, got %d", dropped)
+	}
+
+	deltas, dropped = nicDeltas(prev, cur, nicSelection{include: []string{"eth1", "eth2"}})
+	if len(deltas) != 1 || deltas[0].Interface != "eth1" || dropped != 3 {
+		t.Errorf("filter: expected only eth1 with 3 drops, got %+v and %d drops", deltas, dropped)
+	}
+
+	deltas, dropped = nicDeltas(prev, cur, nicSelection{ignore: []string{"lo", "eth1"}})
+	if len(deltas) != 1 || deltas[0].Interface != "eth0" || dropped != 5 {
+		t.Errorf("ignore list: expected only eth0 with 5 drops, got %+v and %d drops", deltas, dropped)
+	}
+
+	deltas, _ = nicDeltas(prev, cur, nicSelection{include: []string{"eth0", "lo"}, ignore: []string{"lo"}})
+	if len(deltas) != 1 || deltas[0].Interface != "eth0" {
+		t.Errorf("an ignored interface should not be reported when it is also included, got %+v", deltas)
+	}
+}
+
+func TestResolveInterfaces(t *testing.T) {
+	sel, err := resolveInterfaces(" eth0, -lo,,eth1 ,-docker0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sel.include) != 2 || sel.include[0] != "eth0" || sel.include[1] != "eth1" {
+		t.Errorf("unexpected interfaces: %v", sel.include)
+	}
+	if len(sel.ignore) != 2 || sel.ignore[0] != "lo" || sel.ignore[1] != "docker0" {
+		t.Errorf("unexpected ignored interfaces: %v", sel.ignore)
+	}
+	if !sel.monitored("eth0") || sel.monitored("lo") || sel.monitored("eth2") {
+		t.Errorf("unexpected selection: %+v", sel)
+	}
+
+	sel, err = resolveInterfaces("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sel.monitored("eth0") || !sel.monitored("lo") {
+		t.Error("every interface should be monitored without a list")
+	}
+}
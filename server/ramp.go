@@ -0,0 +1,90 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+// maxRampConcurrency limits the concurrency of a single ramp step
+const maxRampConcurrency = 4096
+
+func validateRamp(c shared.Config) error {
+	if len(c.Ramp) == 0 {
+		return nil
+	}
+	if c.TestType != shared.StreamTest && c.TestType != shared.RequestTest {
+		return fmt.Errorf("A ramp schedule is only supported by stream and request tests")
+	}
+	if len(c.Ramp) > shared.MaxRampSteps {
+		return fmt.Errorf("Ramp schedule has %d steps, at most %d are allowed", len(c.Ramp), shared.MaxRampSteps)
+	}
+	if c.RampStepDuration <= 0 {
+		return fmt.Errorf("Invalid ramp step duration (%d), expected at least 1 second", c.RampStepDuration)
+	}
+	for _, s := range c.Ramp {
+		if s.Concurrency <= 0 || s.Concurrency > maxRampConcurrency || s.PayloadSize <= 0 {
+			return fmt.Errorf("Invalid ramp step (%s), expected a concurrency between 1 and %d and a payload size larger than 0", s, maxRampConcurrency)
+		}
+	}
+	return nil
+}
+
+// rampStepAt returns the step of the ramp schedule which runs after elapsed
+func (t *test) rampStepAt(elapsed time.Duration) int {
+	step := int(elapsed / (time.Duration(t.Config.RampStepDuration) * time.Second))
+	return min(step, len(t.Config.Ramp)-1)
+}
+
+// rampReaders creates the readers of a step with its concurrency and
+// payload size, they are ended with the step.
+func (t *test) rampReaders(step int) []*netPerfReader {
+	var ctx context.Context
+	ctx, t.rampCancel = context.WithCancel(t.ctx)
+	c := t.Config
+	c.Concurrency = t.Config.Ramp[step].Concurrency
+	c.PayloadSize = t.Config.Ramp[step].PayloadSize
+
+	readers := t.createReaders(ctx, c)
+	for _, r := range readers {
+		r.rampStep = step
+	}
+	return readers
+}
+
+// endRampStep ends the requests of the current step, the readers are
+// kept until their last data points were generated.
+func (t *test) endRampStep() {
+	t.rampCancel()
+	for _, r := range t.Readers {
+		r.client.CloseIdleConnections()
+	}
+}
+
+// startRampStep replaces the readers of the previous step. Readers are
+// only swapped by the goroutine which generates the data points.
+func (t *test) startRampStep(step int) {
+	t.Readers = t.rampReaders(step)
+	for _, r := range t.Readers {
+		go startPerformanceReader(t, r)
+	}
+}
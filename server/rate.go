@@ -0,0 +1,66 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"time"
+)
+
+const (
+	// maxRequestRate keeps the schedule interval above a microsecond
+	maxRequestRate = 1_000_000
+	// minRequestRate is one request per hour, smaller rates
+	// would overflow the schedule interval.
+	minRequestRate = 1.0 / 3600
+)
+
+// startOpenLoopReader sends requests on a fixed schedule instead of waiting
+// for the previous request to finish. When all concurrency slots are busy
+// the requests which are due are sent as soon as a slot frees up, they keep
+// their scheduled time so the wait is part of their latency. Measuring from
+// the schedule corrects the coordinated omission of closed-loop tests, which
+// send less and hide latency when the network slows down.
+func startOpenLoopReader(t *test, r *netPerfReader) {
+	interval := time.Duration(float64(time.Second) / t.Config.Rate)
+	start := time.Now()
+	var next int64
+	for r.ctx.Err() == nil {
+		// send everything that is due, requests are sent in bursts
+		// when the rate is higher than what time.Sleep can resolve.
+		due := int64(time.Since(start) / interval)
+		for ; next <= due; next++ {
+			scheduled := start.Add(time.Duration(next) * interval)
+			select {
+			case cid := <-r.concurrency:
+				go sendRequestToHost(t, r, cid, scheduled)
+			case <-r.ctx.Done():
+				return
+			}
+		}
+		time.Sleep(time.Until(start.Add(time.Duration(next) * interval)))
+	}
+}
+
+// recordSend counts a request of an open-loop test and how far it was behind its schedule
+func (r *netPerfReader) recordSend(lag time.Duration) {
+	r.m.Lock()
+	r.sent++
+	r.sendLag = max(r.sendLag, lag.Microseconds())
+	r.hasStats = true
+	r.m.Unlock()
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+var errConnectionReleased = errors.New("Websocket connection has been released")
+
+// wsConn serializes writes to a websocket connection, the same
+// connection can be written to by many tests at the same time.
+type wsConn struct {
+	*websocket.Conn
+	wm       sync.Mutex
+	released bool
+}
+
+func newWSConn(con *websocket.Conn) *wsConn {
+	return &wsConn{Conn: con}
+}
+
+func (c *wsConn) WriteJSON(v interface{}) error {
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	if c.released {
+		return errConnectionReleased
+	}
+	return c.Conn.WriteJSON(v)
+}
+
+func (c *wsConn) Close() error {
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	if c.released {
+		return errConnectionReleased
+	}
+	return c.Conn.Close()
+}
+
+// release has to be called before the websocket handler returns,
+// the underlying connection is reused by fiber after that.
+func (c *wsConn) release() {
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	c.released = true
+}
+
+// testRegistry keeps track of all tests known to the server.
+type testRegistry struct {
+	m     sync.RWMutex
+	tests map[string]*test
+	order []string
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		tests: make(map[string]*test),
+		order: make([]string, 0),
+	}
+}
+
+// Add registers a test, it fails if a test with the same ID is still running.
+func (r *testRegistry) Add(t *test) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	old, ok := r.tests[t.ID]
+	if ok {
+		if old.ctx.Err() == nil {
+			return fmt.Errorf("Test with ID (%s) is already running", t.ID)
+		}
+		r.order = slices.DeleteFunc(r.order, func(id string) bool { return id == t.ID })
+	}
+	r.tests[t.ID] = t
+	r.order = append(r.order, t.ID)
+	return nil
+}
+
+func (r *testRegistry) Get(id string) (t *test, ok bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	t, ok = r.tests[id]
+	return
+}
+
+func (r *testRegistry) Remove(id string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.tests, id)
+	r.order = slices.DeleteFunc(r.order, func(v string) bool { return v == id })
+}
+
+// RemoveIf removes the test with the ID of t only if it is still t, a test
+// started with the same ID in the meantime is kept.
+func (r *testRegistry) RemoveIf(t *test) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.tests[t.ID] != t {
+		return false
+	}
+	delete(r.tests, t.ID)
+	r.order = slices.DeleteFunc(r.order, func(v string) bool { return v == t.ID })
+	return true
+}
+
+func (r *testRegistry) Len() int {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return len(r.tests)
+}
+
+// Filter returns a snapshot of all tests in the order they were
+// added, or only the test matching id if id is not empty.
+func (r *testRegistry) Filter(id string) (list []*test) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	list = make([]*test, 0, len(r.order))
+	for _, v := range r.order {
+		if id != "" && v != id {
+			continue
+		}
+		list = append(list, r.tests[v])
+	}
+	return
+}
+
+// Range calls fn for every test until fn returns false, the registry
+// is not locked while fn is running.
+func (r *testRegistry) Range(fn func(t *test) bool) {
+	for _, t := range r.Filter("") {
+		if !fn(t) {
+			return
+		}
+	}
+}
+
+func (t *test) AddListener(id string, con *wsConn) {
+	t.consLock.Lock()
+	defer t.consLock.Unlock()
+	t.cons[id] = con
+}
+
+func (t *test) RemoveListener(id string) {
+	t.consLock.Lock()
+	defer t.consLock.Unlock()
+	delete(t.cons, id)
+}
+
+// Listeners returns a snapshot of all connections listening to the test.
+func (t *test) Listeners() map[string]*wsConn {
+	t.consLock.Lock()
+	defer t.consLock.Unlock()
+	list := make(map[string]*wsConn, len(t.cons))
+	for k, v := range t.cons {
+		if v == nil {
+			continue
+		}
+		list[k] = v
+	}
+	return list
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func newRegistryTest(id string) *test {
+	t := &test{ID: id, cons: make(map[string]*wsConn)}
+	t.ctx, t.cancel = context.WithCancelCause(context.Background())
+	return t
+}
+
+func TestRegistryAddRunningID(t *testing.T) {
+	r := newTestRegistry()
+	first := newRegistryTest("a")
+	if err := r.Add(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(newRegistryTest("a")); err == nil {
+		t.Fatal("a second test with the ID of a running test was added")
+	}
+
+	first.cancel(errors.New("done"))
+	second := newRegistryTest("a")
+	if err := r.Add(second); err != nil {
+		t.Fatal("the ID of a finished test was not released:", err)
+	}
+	got, ok := r.Get("a")
+	if !ok || got != second {
+		t.Fatal("the finished test was not replaced")
+	}
+	if r.Len() != 1 || len(r.Filter("")) != 1 {
+		t.Fatalf("expected one test, got %d", r.Len())
+	}
+}
+
+func TestRegistryRemoveIf(t *testing.T) {
+	r := newTestRegistry()
+	first := newRegistryTest("a")
+	if err := r.Add(first); err != nil {
+		t.Fatal(err)
+	}
+	first.cancel(errors.New("done"))
+	second := newRegistryTest("a")
+	if err := r.Add(second); err != nil {
+		t.Fatal(err)
+	}
+
+	// the finished test was replaced after it was picked for eviction
+	if r.RemoveIf(first) {
+		t.Fatal("a replaced test removed the test which replaced it")
+	}
+	if got, ok := r.Get("a"); !ok || got != second {
+		t.Fatal("the running test was dropped from the registry")
+	}
+	if !r.RemoveIf(second) {
+		t.Fatal("the test was not removed")
+	}
+	if r.Len() != 0 || len(r.Filter("")) != 0 {
+		t.Fatalf("expected no tests, got %d", r.Len())
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := newTestRegistry()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				id := strconv.Itoa(i % 20)
+				nt := newRegistryTest(id)
+				if r.Add(nt) == nil {
+					nt.AddListener(strconv.Itoa(w), nil)
+				}
+				if got, ok := r.Get(id); ok {
+					got.AddListener("listener-"+strconv.Itoa(w), nil)
+					_ = got.Listeners()
+					got.RemoveListener("listener-" + strconv.Itoa(w))
+					if i%3 == 0 {
+						got.cancel(errors.New("done"))
+					}
+				}
+				for _, lt := range r.Filter("") {
+					_ = lt.Listeners()
+				}
+				r.Range(func(rt *test) bool {
+					return rt.ID != id
+				})
+				_ = r.Filter(id)
+				_ = r.Len()
+				if i%7 == 0 {
+					r.Remove(id)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	ids := make(map[string]bool)
+	for _, rt := range r.Filter("") {
+		if ids[rt.ID] {
+			t.Fatal("test listed twice:", rt.ID)
+		}
+		ids[rt.ID] = true
+		if got, ok := r.Get(rt.ID); !ok || got != rt {
+			t.Fatal("listed test is not registered:", rt.ID)
+		}
+	}
+	if len(ids) != r.Len() {
+		t.Fatalf("listed %d tests, registry holds %d", len(ids), r.Len())
+	}
+}
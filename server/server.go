@@ -20,6 +20,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,9 +29,11 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/netip"
 	"os"
-	"runtime"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -58,28 +61,104 @@ var (
 	realIP           = ""
 	testFolderSuffix = "hperf-tests"
 	basePath         = "./"
-	tests            = make([]*test, 0)
-	testLock         = sync.Mutex{}
+	tests            = newTestRegistry()
+
+	certFile         = ""
+	keyFile          = ""
+	peerCAFile       = ""
+	peerFingerprints = make([]string, 0)
 )
 
+// Options controls how the hperf server is started
+type Options struct {
+	Address     string
+	RealIP      string
+	StoragePath string
+
+	// TLS for the API and websocket, if CertFile and KeyFile are empty
+	// and SelfSigned is false the server listens in plain text.
+	CertFile   string
+	KeyFile    string
+	SelfSigned bool
+
+	// Used to verify other servers when running tests against them.
+	CAFile       string
+	Fingerprints []string
+
+	// How long finished tests are kept in memory, zero uses the default.
+	Retention time.Duration
+
+	// Result files are rotated when they reach RotateSize bytes or are
+	// older than RotateInterval. Closed files are compressed using
+	// Compression (none, gzip or zstd).
+	RotateSize     int64
+	RotateInterval time.Duration
+	Compression    string
+
+	// Port for the raw TCP test listener, defaults to the API port + 1
+	TCPPort string
+
+	// Comma separated interfaces reported in data points, "auto" selects
+	// the interface of the server address. All interfaces when empty,
+	// interfaces prefixed with "-" are never reported.
+	Interfaces string
+
+	// Shared secret used to authenticate clients and other servers,
+	// when empty all requests are accepted.
+	AuthKey string
+}
+
 type test struct {
 	ID      string
 	Config  shared.Config
 	Started time.Time
 
+	stateLock sync.Mutex
+	state     shared.TestState
+	created   time.Time
+	ended     time.Time
+	cause     string
+
 	ctx    context.Context
 	cancel context.CancelCauseFunc
-
-	Readers  []*netPerfReader
-	errors   []shared.TError
-	errMap   map[string]struct{}
-	errIndex atomic.Int32
-	DPS      []shared.DP
-	M        sync.Mutex
-
-	DataFile      *os.File
-	DataFileIndex int
-	cons          map[string]*websocket.Conn
+	// ends the readers of the current ramp step
+	rampCancel context.CancelFunc
+
+	Readers   []*netPerfReader
+	tlsConfig *tls.Config
+	errors    []shared.TError
+	errMap    map[string]struct{}
+	errIndex  atomic.Int32
+	DPS       []shared.DP
+	HostDPS   []shared.HostDP
+	M         sync.Mutex
+
+	DataFile        *os.File
+	DataFileIndex   int
+	DataFileCreated time.Time
+	// size of the metadata record at the start of the data file
+	DataFileMetaSize int
+
+	// interface counters at the last data point, only
+	// used by the goroutine running the test.
+	nics map[string]shared.NICStats
+	cpu  *cpuSample
+
+	cons     map[string]*wsConn
+	consLock sync.Mutex
+
+	// tests with socket options only, what the kernel applied
+	// to the last connection accepted from every peer.
+	acceptedSockets map[string]*shared.SocketState
+	socketsLock     sync.Mutex
+
+	// authenticates the requests of the test against its peers
+	token     peerToken
+	tokenLock sync.Mutex
+
+	// tests with clock sync only, the probes of every peer
+	clocks     map[string]*peerClock
+	clocksLock sync.Mutex
 }
 
 func (t *test) AddError(err error, id string) {
@@ -99,9 +178,12 @@ func (t *test) AddError(err error, id string) {
 	t.errMap[id] = struct{}{}
 }
 
-func RunServer(ctx context.Context, address string, rIP string, storagePath string) (err error) {
-	cancelContext, cancel := context.WithCancel(ctx)
-	defer cancel()
+func RunServer(ctx context.Context, o Options) (err error) {
+	cancelContext, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stopServer = cancel
+
+	storagePath := o.StoragePath
 
 	if storagePath == "" {
 		basePath, err = os.Getwd()
@@ -129,8 +211,56 @@ func RunServer(ctx context.Context, address string, rIP string, storagePath stri
 		return err
 	}
 
-	bindAddress = address
-	realIP = rIP
+	bindAddress = o.Address
+	realIP = o.RealIP
+
+	host, port, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return err
+	}
+	tcpPort := o.TCPPort
+	if tcpPort == "" {
+		tcpPort, err = defaultTCPPort(port)
+		if err != nil {
+			return err
+		}
+	}
+	tcpAddress = net.JoinHostPort(host, tcpPort)
+
+	monitoredNICs, err = resolveInterfaces(o.Interfaces)
+	if err != nil {
+		return err
+	}
+	if len(monitoredNICs.include) > 0 {
+		shared.DEBUG("Monitored interfaces:", monitoredNICs.include)
+	}
+	if len(monitoredNICs.ignore) > 0 {
+		shared.DEBUG("Ignored interfaces:", monitoredNICs.ignore)
+	}
+
+	authKey = o.AuthKey
+	if o.Retention > 0 {
+		testRetention = o.Retention
+	}
+
+	err = validateCompression(o.Compression)
+	if err != nil {
+		return err
+	}
+	rotateSize = o.RotateSize
+	rotateInterval = o.RotateInterval
+	if o.Compression != "" {
+		compression = o.Compression
+	}
+	if authKey == "" {
+		shared.INFO("WARNING: no auth key configured, the server API is unauthenticated")
+	}
+
+	err = setupTLS(o, storagePath)
+	if err != nil {
+		return err
+	}
+
 	shared.INFO("starting 'hperf' server on:", bindAddress)
 	err = startAPIandWS(cancelContext)
 	if err != nil {
@@ -153,7 +283,9 @@ func startAPIandWS(ctx context.Context) (err error) {
 		return fiber.ErrUpgradeRequired
 	})
 
-	httpServer.Get("/ws/:id", websocket.New(func(con *websocket.Conn) {
+	httpServer.Get("/ws/:id", websocket.New(func(c *websocket.Conn) {
+		con := newWSConn(c)
+		defer con.release()
 		var (
 			msg []byte
 			err error
@@ -177,6 +309,7 @@ func startAPIandWS(ctx context.Context) (err error) {
 				shared.DEBUG("Error reading websocket message:", err)
 				break
 			}
+			received := time.Now()
 
 			signal := new(shared.WebsocketSignal)
 			err := json.Unmarshal(msg, signal)
@@ -190,6 +323,20 @@ func startAPIandWS(ctx context.Context) (err error) {
 				fmt.Printf("WebsocketSignal: %+v\n", signal)
 			}
 
+			err = authenticateSignal(signal)
+			if err != nil {
+				logRejected(con.RemoteAddr().String(), "/ws", err)
+				SendError(con, fmt.Errorf("Unauthorized: %s", err))
+				continue
+			}
+
+			if signal.ProtocolVersion != shared.ProtocolVersion {
+				SendError(con, fmt.Errorf("Client protocol version %d does not match server protocol version %d (hperf %s)",
+					signal.ProtocolVersion, shared.ProtocolVersion, shared.Version))
+				SendDone(con)
+				continue
+			}
+
 			switch signal.SType {
 			case shared.RunTest:
 				go createAndRunTest(con, *signal)
@@ -201,12 +348,16 @@ func startAPIandWS(ctx context.Context) (err error) {
 				go getTestOnServer(con, *signal)
 			case shared.Ping:
 				go replyToPing(con)
+			case shared.ClockSync:
+				replyToClockSync(con, signal, received)
 			case shared.DeleteTests:
 				go deleteTestsFromDisk(con, *signal)
 			case shared.StopAllTests:
 				go stopAllTests(con, *signal)
-			case shared.Exit:
-				os.Exit(1)
+			case shared.TestStatus:
+				go sendTestStatus(con, *signal)
+			case shared.Shutdown:
+				go shutdownFromSignal(con, *signal)
 			default:
 				if signal.Config.Debug {
 					fmt.Println("unrecognized command")
@@ -216,18 +367,86 @@ func startAPIandWS(ctx context.Context) (err error) {
 		}
 	}))
 
-	httpServer.Put("/requests", func(c *fiber.Ctx) error {
+	httpServer.Put("/requests", authenticatePeer, acceptTestSocket, func(c *fiber.Ctx) error {
+		p, err := requestPattern(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		if p != nil {
+			return verifyUpload(c, p, bytes.NewBuffer(c.Body()))
+		}
 		io.Copy(io.Discard, bytes.NewBuffer(c.Body()))
 		return c.SendStatus(200)
 	})
 
-	httpServer.Put("/stream", func(c *fiber.Ctx) error {
+	httpServer.Put("/stream", authenticatePeer, acceptTestSocket, func(c *fiber.Ctx) error {
+		p, err := requestPattern(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		if p != nil {
+			return verifyUpload(c, p, c.Request().BodyStream())
+		}
 		io.Copy(io.Discard, c.Request().BodyStream())
 		return c.SendStatus(200)
 	})
 
+	httpServer.Get("/requests", authenticatePeer, acceptTestSocket, func(c *fiber.Ctx) error {
+		size := c.QueryInt("size", -1)
+		if size < 0 {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		p, err := requestPattern(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		if p != nil {
+			sendPattern(c, p, size)
+			return nil
+		}
+		body, err := contentReader(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		c.Response().SetBodyStream(io.LimitReader(body, int64(size)), size)
+		return nil
+	})
+
+	httpServer.Get("/stream", authenticatePeer, acceptTestSocket, func(c *fiber.Ctx) error {
+		p, err := requestPattern(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		// streams until the peer closes the connection
+		if p != nil {
+			sendPattern(c, p, -1)
+			return nil
+		}
+		body, err := contentReader(c)
+		if err != nil {
+			return c.SendStatus(http.StatusBadRequest)
+		}
+		c.Response().SetBodyStream(body, -1)
+		return nil
+	})
+
+	err = listenUDP(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = listenTCP(ctx)
+	if err != nil {
+		return err
+	}
+
 	go func() {
-		err = httpServer.Listen(bindAddress)
+		var err error
+		if certFile != "" {
+			err = httpServer.ListenTLS(bindAddress, certFile, keyFile)
+		} else {
+			err = httpServer.Listen(bindAddress)
+		}
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -244,19 +463,93 @@ func startAPIandWS(ctx context.Context) (err error) {
 		default:
 		}
 		if ctx.Err() != nil {
+			drainServer(context.Cause(ctx).Error(), false)
 			httpServer.Shutdown()
 			return
 		}
+		evictFinishedTests()
 		time.Sleep(1 * time.Second)
 	}
 }
 
+// localAddress is the address other servers use to reach this server
+func localAddress() string {
+	if realIP != "" {
+		return realIP
+	}
+	return bindAddress
+}
+
+func setupTLS(o Options, storagePath string) (err error) {
+	peerCAFile = o.CAFile
+	peerFingerprints = o.Fingerprints
+
+	// validate the peer settings early so tests do not fail later on
+	_, err = shared.NewTLSConfig(peerCAFile, peerFingerprints)
+	if err != nil {
+		return err
+	}
+
+	certFile = o.CertFile
+	keyFile = o.KeyFile
+	if o.SelfSigned && certFile == "" && keyFile == "" {
+		if storagePath == "" {
+			storagePath, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+		host, _, _ := net.SplitHostPort(bindAddress)
+		certFile, keyFile, err = shared.GenerateSelfSignedCert(storagePath, []string{host, realIP})
+		if err != nil {
+			return fmt.Errorf("Unable to generate self-signed certificate: %s", err)
+		}
+	}
+
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return errors.New("Both a certificate and a key are required to enable TLS")
+	}
+
+	_, err = tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("Unable to load certificate and key: %s", err)
+	}
+
+	fp, err := shared.CertificateFingerprint(certFile)
+	if err != nil {
+		return err
+	}
+	shared.INFO("TLS certificate:", certFile)
+	shared.INFO("TLS fingerprint (sha256):", fp)
+	return nil
+}
+
+// newPeerTLSConfig combines the servers own CA and pinning settings
+// with the fingerprints sent by the client for the current test.
+func newPeerTLSConfig(c shared.Config) (*tls.Config, error) {
+	fps := slices.Clone(peerFingerprints)
+	fps = append(fps, c.TLSFingerprints...)
+	return shared.NewTLSConfig(peerCAFile, fps)
+}
+
 var (
 	currentMemoryStat *mem.VirtualMemoryStat
-	droppedPackets    int
 	cpuPercent        float64
+	statsLock         sync.Mutex
 )
 
+func getCurrentServerStats() (memoryPercent int, cpuUsed int) {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	if currentMemoryStat != nil {
+		memoryPercent = int(currentMemoryStat.UsedPercent)
+	}
+	return memoryPercent, int(cpuPercent)
+}
+
 func getServerStats(id byte) {
 	defer func() {
 		r := recover()
@@ -267,57 +560,35 @@ func getServerStats(id byte) {
 		routineMonitor <- id
 	}()
 
-	var err error
-	currentMemoryStat, err = mem.VirtualMemory()
+	memStat, err := mem.VirtualMemory()
 	if err != nil {
 		fmt.Println(err)
 	}
 
-	droppedPackets, err = GetDroppedPackets()
-	if err != nil {
-		fmt.Println(err)
-	}
 	percent, err := cpu.Percent(time.Second, false)
 	if err != nil {
 		fmt.Println(err)
 	}
-	if len(percent) > 0 {
-		cpuPercent = percent[0]
-	}
-}
 
-func GetDroppedPackets() (total int, err error) {
-	if runtime.GOOS != "linux" {
-		return 0, nil
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	if memStat != nil {
+		currentMemoryStat = memStat
 	}
-	data, err := os.ReadFile("/proc/net/dev")
-	if err != nil {
-		return 0, err
-	}
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines[2:] {
-		fields := strings.Fields(line)
-		if len(fields) < 5 {
-			continue
-		}
-		dropped, err := strconv.Atoi(fields[4]) // Field 4 is for dropped packets
-		if err != nil {
-			return 0, err
-		}
-		total += dropped
+	if len(percent) > 0 {
+		cpuPercent = percent[0]
 	}
-	return
 }
 
 var routineMonitor = make(chan byte, 100)
 
-func replyToPing(c *websocket.Conn) {
+func replyToPing(c *wsConn) {
 	msg := new(shared.WebsocketSignal)
 	msg.SType = shared.Pong
 	_ = c.WriteJSON(msg)
 }
 
-func SendError(c *websocket.Conn, e error) error {
+func SendError(c *wsConn, e error) error {
 	if e == nil {
 		return nil
 	}
@@ -327,82 +598,146 @@ func SendError(c *websocket.Conn, e error) error {
 	return c.WriteJSON(msg)
 }
 
-func stopAllTests(con *websocket.Conn, s shared.WebsocketSignal) {
+func stopAllTests(con *wsConn, s shared.WebsocketSignal) {
 	defer SendDone(con)
-	for i := range tests {
-		if s.Config.TestID != "" && s.Config.TestID != tests[i].ID {
-			continue
-		}
+	for _, t := range tests.Filter(s.Config.TestID) {
 		if s.Config.Debug {
-			fmt.Println("Stopping:", tests[i].ID)
+			fmt.Println("Stopping:", t.ID)
 		}
-		tests[i].cancel(fmt.Errorf("Client called StopAllTests"))
+		t.cancel(errTestStopped)
 	}
 }
 
-func SendPing(c *websocket.Conn) error {
+func SendPing(c *wsConn) error {
 	msg := new(shared.WebsocketSignal)
 	msg.SType = shared.Ping
 	msg.Code = shared.OK
+	msg.Info = shared.LocalServerInfo()
 	return c.WriteJSON(msg)
 }
 
-func SendOK(c *websocket.Conn, t shared.SignalType) error {
+func SendOK(c *wsConn, t shared.SignalType) error {
 	msg := new(shared.WebsocketSignal)
 	msg.SType = t
 	msg.Code = shared.OK
 	return c.WriteJSON(msg)
 }
 
-func SendDone(c *websocket.Conn) error {
+func SendDone(c *wsConn) error {
 	msg := new(shared.WebsocketSignal)
 	msg.SType = shared.Done
 	msg.Code = shared.OK
 	return c.WriteJSON(msg)
 }
 
-func newTest(c shared.Config) (t *test, err error) {
-	testLock.Lock()
-	defer testLock.Unlock()
+func SendDoneWithReason(c *wsConn, reason string) error {
+	msg := new(shared.WebsocketSignal)
+	msg.SType = shared.Done
+	msg.Code = shared.OK
+	msg.Reason = reason
+	return c.WriteJSON(msg)
+}
 
+func newTest(c shared.Config) (t *test, err error) {
 	t = new(test)
 	t.errMap = make(map[string]struct{})
-	t.cons = make(map[string]*websocket.Conn)
-	t.Started = time.Now()
+	t.cons = make(map[string]*wsConn)
+	t.acceptedSockets = make(map[string]*shared.SocketState)
+	t.state = shared.TestQueued
+	t.created = time.Now()
 	t.Config = c
 	t.DPS = make([]shared.DP, 0)
 	t.ID = c.TestID
 	t.ctx, t.cancel = context.WithCancelCause(context.Background())
+	cancel := t.cancel
+	defer func() {
+		if err != nil {
+			cancel(err)
+		}
+	}()
 
-	if c.Save {
-		resetTestFiles(t)
-		newTestFile(t)
+	t.tlsConfig, err = newPeerTLSConfig(c)
+	if err != nil {
+		return nil, err
 	}
 
-	t.Readers = make([]*netPerfReader, 0)
-	readersCreated := 0
+	if c.TestType == shared.TCPTest && t.Config.TCPPort == "" {
+		t.Config.TCPPort, err = defaultTCPPort(c.Port)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	for i := range c.Hosts {
+	t.Config.PayloadContent, err = shared.ParsePayloadContent(string(c.PayloadContent))
+	if err != nil {
+		return nil, err
+	}
 
-		joinedHostPort := net.JoinHostPort(c.Hosts[i], c.Port)
-		if realIP != "" && strings.Contains(joinedHostPort, realIP) {
-			continue
+	if c.Rate > 0 && c.TestType != shared.RequestTest && c.TestType != shared.ChurnTest {
+		return nil, fmt.Errorf("A request rate is only supported by request and churn tests")
+	}
+	if c.Rate != 0 && !(c.Rate >= minRequestRate && c.Rate <= maxRequestRate) {
+		return nil, fmt.Errorf("Invalid request rate (%g), expected between one request per hour and %d requests per second", c.Rate, maxRequestRate)
+	}
+
+	if c.TestType == shared.TCPTest && c.PayloadSize <= 0 {
+		return nil, fmt.Errorf("Raw TCP tests need a payload size larger than 0")
+	}
+
+	if c.TestType == shared.UDPTest && (c.PacketRate < 0 || c.PacketRate > shared.MaxPacketRate) {
+		return nil, fmt.Errorf("Invalid packet rate (%d), expected at most %d packets per second", c.PacketRate, shared.MaxPacketRate)
+	}
+
+	err = validateRamp(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSocketOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Verify {
+		switch c.TestType {
+		case shared.RequestTest, shared.StreamTest, shared.ChurnTest:
+		default:
+			return nil, fmt.Errorf("Payload verification is only supported by HTTP tests")
 		}
-		if joinedHostPort == bindAddress {
-			continue
+		if c.PayloadSize <= 0 {
+			return nil, fmt.Errorf("Payload verification needs a payload size larger than 0")
 		}
-		t.Readers = append(t.Readers,
-			newPerformanceReaderForASingleHost(c, c.Hosts[i], c.Port),
-		)
-		readersCreated++
-
+		// verified payloads are always filled with the pattern
+		t.Config.PayloadContent = shared.ContentPattern
 	}
 
-	if readersCreated == 0 {
+	if len(c.Ramp) > 0 {
+		t.Readers = t.rampReaders(0)
+	} else {
+		t.Readers = t.createReaders(t.ctx, c)
+	}
+	if len(t.Readers) == 0 {
 		return nil, fmt.Errorf("No performance readers were created, please revise your config")
 	}
 
-	tests = append(tests, t)
+	// the ID is reserved before touching any files, the result
+	// files of a running test with the same ID are kept.
+	err = tests.Add(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Save {
+		err = resetTestFiles(t)
+		if err == nil {
+			_, err = newTestFile(t)
+		}
+		if err != nil {
+			discardTestFile(t)
+			tests.RemoveIf(t)
+			return nil, err
+		}
+	}
 	return t, nil
 }
 
@@ -410,11 +745,31 @@ type netPerfReader struct {
 	hasStats bool
 	m        sync.Mutex
 
-	buf []byte
+	// ends the requests of the reader, ramp tests end
+	// the readers of a step before starting the next.
+	ctx context.Context
+	// ramp tests only, the step the reader belongs to
+	rampStep int
 
-	addr   string
-	ip     string
-	client *http.Client
+	buf []byte
+	// the pattern of buf when payloads are verified
+	pattern *shared.PayloadPattern
+
+	addr      string
+	ip        string
+	direction shared.TestDirection
+	client    *http.Client
+	// UDP tests only, the resolved address of the peer
+	peer netip.Addr
+
+	// open test connections, sampled for TCP_INFO
+	connsLock     sync.Mutex
+	conns         map[*trackedConn]struct{}
+	closedRetrans uint64
+	// tests with socket options only, what the kernel
+	// applied to the last connection to the peer
+	socketOptions shared.SocketOptions
+	socket        *shared.SocketState
 
 	TXCount atomic.Uint64
 	TX      atomic.Uint64
@@ -426,6 +781,28 @@ type netPerfReader struct {
 	RMSH  int64
 	RMSL  int64
 
+	rmsHistogram  *shared.Histogram
+	ttfbHistogram *shared.Histogram
+
+	// httptrace timings of the requests in the current interval
+	phases           shared.RequestPhases
+	connectHistogram *shared.Histogram
+	failures         connFailures
+
+	// payloads which did not match the pattern during the interval
+	mismatches uint64
+	corrupted  uint64
+
+	// MTU tests only, the result of the last probe search
+	pathMTU      int
+	interfaceMTU int
+	kernelMTU    int
+
+	// open-loop tests only, requests sent during the interval and
+	// the highest delay behind the schedule in microseconds
+	sent    uint64
+	sendLag int64
+
 	lastDataPointTime time.Time
 }
 
@@ -439,27 +816,41 @@ type asyncReader struct {
 	c              *shared.Config
 }
 
+func (r *netPerfReader) registerTTFB(since int64) {
+	r.m.Lock()
+	if since > r.TTFBH {
+		r.TTFBH = since
+	}
+	if since < r.TTFBL {
+		r.TTFBL = since
+	}
+	r.ttfbHistogram.Record(since)
+	r.hasStats = true
+	r.m.Unlock()
+}
+
 func (a *asyncReader) Read(b []byte) (n int, err error) {
-	a.pr.m.Lock()
 	if !a.ttfbRegistered {
-		since := time.Since(a.start).Microseconds()
 		a.ttfbRegistered = true
-		if since > a.pr.TTFBH {
-			a.pr.TTFBH = since
-		}
-		if since < a.pr.TTFBL {
-			a.pr.TTFBL = since
-		}
+		a.pr.registerTTFB(time.Since(a.start).Microseconds())
+	} else {
+		a.pr.m.Lock()
+		a.pr.hasStats = true
+		a.pr.m.Unlock()
 	}
-	a.pr.hasStats = true
-	a.pr.m.Unlock()
 
 	if a.ctx.Err() != nil {
 		return 0, io.EOF
 	}
 
 	if a.c.TestType == shared.StreamTest {
-		n = copy(b, a.pr.buf)
+		// streams repeat the buffer, verified streams need
+		// every byte of it in order.
+		n = copy(b, a.pr.buf[a.i:])
+		a.i += int64(n)
+		if a.i >= int64(len(a.pr.buf)) {
+			a.i = 0
+		}
 		a.pr.TX.Add(uint64(n))
 		return n, nil
 	}
@@ -473,8 +864,45 @@ func (a *asyncReader) Read(b []byte) (n int, err error) {
 	return n, nil
 }
 
-func createAndRunTest(con *websocket.Conn, signal shared.WebsocketSignal) {
-	defer SendDone(con)
+// createReaders creates a reader for every peer and direction of the test
+func (t *test) createReaders(ctx context.Context, c shared.Config) []*netPerfReader {
+	readers := make([]*netPerfReader, 0)
+	for i := range c.Hosts {
+
+		joinedHostPort := net.JoinHostPort(c.Hosts[i], c.Port)
+		if realIP != "" && strings.Contains(joinedHostPort, realIP) {
+			continue
+		}
+		if joinedHostPort == bindAddress {
+			continue
+		}
+		directions := c.Direction.Directions()
+		if c.TestType == shared.UDPTest || c.TestType == shared.TCPTest || c.TestType == shared.MTUTest {
+			// every server sends to all of its peers, which covers both directions of a link
+			directions = []shared.TestDirection{shared.DirectionUpload}
+		}
+		for _, d := range directions {
+			r := newPerformanceReaderForASingleHost(c, c.Hosts[i], c.Port, d, t.tlsConfig)
+			r.ctx = ctx
+			readers = append(readers, r)
+		}
+
+	}
+	return readers
+}
+
+func createAndRunTest(con *wsConn, signal shared.WebsocketSignal) {
+	// reason is only set when the test was canceled
+	var reason string
+	defer func() {
+		_ = SendDoneWithReason(con, reason)
+	}()
+
+	if !startRunningTest() {
+		SendError(con, errDraining)
+		return
+	}
+	defer runningTests.Done()
 
 	test, err := newTest(signal.Config)
 	if err != nil {
@@ -487,17 +915,35 @@ func createAndRunTest(con *websocket.Conn, signal shared.WebsocketSignal) {
 		}()
 	}
 	defer test.cancel(fmt.Errorf("testing finished"))
+	defer udpFlows.Remove(test.ID)
+	defer finishTestFile(test)
+	defer test.finish()
+
+	test.nics, err = readNICCounters()
+	if err != nil {
+		test.AddError(err, "nic-counters")
+	}
+	test.cpu, err = readCPUSample()
+	if err != nil {
+		test.AddError(err, "cpu-stats")
+	}
 
+	test.setRunning()
 	start := time.Now()
+	step := 0
 	for i := range test.Readers {
 		go startPerformanceReader(test, test.Readers[i])
 	}
 
+	// the connection which started the test gets its Done from this
+	// function, not from a drain which runs once the test returned.
 	conUID := uuid.NewString()
-	test.cons[conUID] = con
+	test.AddListener(conUID, con)
+	defer test.RemoveListener(conUID)
 
 	for {
 		if test.ctx.Err() != nil {
+			reason = context.Cause(test.ctx).Error()
 			return
 		}
 
@@ -509,23 +955,34 @@ func createAndRunTest(con *websocket.Conn, signal shared.WebsocketSignal) {
 			fmt.Println("Duration: ", signal.Config.TestID, time.Since(start).Seconds())
 		}
 
+		// the previous step is ended before its last interval is
+		// flushed, its data points cover it until its requests stopped.
+		next := step
+		if len(test.Config.Ramp) > 0 {
+			next = test.rampStepAt(time.Since(start))
+		}
+		if next != step {
+			test.endRampStep()
+		}
+
 		generateDataPoints(test)
 		_ = sendAndSaveData(test)
+
+		if next != step {
+			step = next
+			test.startRampStep(step)
+		}
 	}
 }
 
-func listenToLiveTests(con *websocket.Conn, s shared.WebsocketSignal) {
+func listenToLiveTests(con *wsConn, s shared.WebsocketSignal) {
 	uid := uuid.NewString()
 
-	for i := range tests {
-		if s.Config.TestID != "" && tests[i].ID != s.Config.TestID {
-			continue
-		}
+	for _, t := range tests.Filter(s.Config.TestID) {
 		if s.Config.Debug {
-			fmt.Println("Listen:", tests[i].ID, "DPS:", len(tests[i].DPS), "ERR:", len(tests[i].errors))
+			fmt.Println("Listen:", t.ID)
 		}
-
-		tests[i].cons[uid] = con
+		t.AddListener(uid, con)
 	}
 }
 
@@ -565,6 +1022,20 @@ func sendAndSaveData(t *test) (err error) {
 	}
 	t.DPS = make([]shared.DP, 0)
 
+	for i := range t.HostDPS {
+		wss.DataPoint.Hosts = append(wss.DataPoint.Hosts, t.HostDPS[i])
+		if t.Config.Save {
+			fileb, err := json.Marshal(t.HostDPS[i])
+			if err != nil {
+				t.AddError(err, "hostpoint-marshaling")
+			}
+			t.DataFile.Write(shared.HostPoint.String())
+			t.DataFile.Write(fileb)
+			t.DataFile.Write([]byte{10})
+		}
+	}
+	t.HostDPS = t.HostDPS[:0]
+
 	t.M.Lock()
 	errorsClone := make([]shared.TError, 0)
 	for _, v := range t.errors {
@@ -587,18 +1058,18 @@ func sendAndSaveData(t *test) (err error) {
 		}
 	}
 
-	for i := range t.cons {
-		if t.cons[i] == nil {
-			continue
-		}
+	if t.Config.Save {
+		rotateTestFile(t)
+	}
 
-		err = t.cons[i].WriteJSON(wss)
+	for id, con := range t.Listeners() {
+		err = con.WriteJSON(wss)
 		if err != nil {
 			if t.Config.Debug {
 				fmt.Println("Unable to send data point:", err)
 			}
-			t.cons[i].Close()
-			delete(t.cons, i)
+			con.Close()
+			t.RemoveListener(id)
 			continue
 		}
 	}
@@ -606,17 +1077,42 @@ func sendAndSaveData(t *test) (err error) {
 }
 
 func generateDataPoints(t *test) {
+	t.M.Lock()
+	errCount := len(t.errors)
+	t.M.Unlock()
+
+	memoryPercent, cpuUsed := getCurrentServerStats()
+
+	nics, err := readNICCounters()
+	if err != nil {
+		t.AddError(err, "nic-counters")
+	}
+	nicStats, dropped := nicDeltas(t.nics, nics, monitoredNICs)
+	t.nics = nics
+
+	cpuStats, err := readCPUSample()
+	if err != nil {
+		t.AddError(err, "cpu-stats")
+	}
+
+	// all data points of an interval share the same time so
+	// interface counters can be matched to a single sample.
+	created := time.Now()
+	cores := coreStats(t.cpu, cpuStats)
+
 	for ri, rv := range t.Readers {
 		if rv == nil {
 			continue
 		}
 
-		if !rv.hasStats {
+		r := t.Readers[ri]
+
+		r.m.Lock()
+		if !r.hasStats {
+			r.m.Unlock()
 			continue
 		}
 
-		r := t.Readers[ri]
-
 		tx := r.TX.Swap(0)
 		totalSecs := time.Since(r.lastDataPointTime).Seconds()
 		r.lastDataPointTime = time.Now()
@@ -625,45 +1121,104 @@ func generateDataPoints(t *test) {
 		d := shared.DP{
 			Type:              t.Config.TestType,
 			TestID:            t.ID,
-			Created:           time.Now(),
+			Created:           created,
 			TX:                uint64(txtotal),
 			TXTotal:           tx,
 			TXCount:           r.TXCount.Load(),
 			Remote:            r.addr,
+			Direction:         r.direction,
 			TTFBL:             r.TTFBL,
 			TTFBH:             r.TTFBH,
 			RMSL:              r.RMSL,
 			RMSH:              r.RMSH,
-			ErrCount:          len(t.errors),
-			DroppedPackets:    droppedPackets,
-			MemoryUsedPercent: int(currentMemoryStat.UsedPercent),
-			CPUUsedPercent:    int(cpuPercent),
+			RMSHistogram:      r.rmsHistogram.Encode(),
+			TTFBHistogram:     r.ttfbHistogram.Encode(),
+			ErrCount:          errCount,
+			DroppedPackets:    dropped,
+			MemoryUsedPercent: memoryPercent,
+			CPUUsedPercent:    cpuUsed,
 		}
+		addCPUStats(t.cpu, cpuStats, cores, &d)
 
-		if realIP != "" {
-			d.Local = realIP
-		} else {
-			d.Local = bindAddress
+		if !r.phases.Empty() {
+			phases := r.phases
+			d.Phases = &phases
+			d.ConnsPerSecond = uint64(float64(phases.NewConns) / totalSecs)
+		}
+		d.ConnectHistogram = r.connectHistogram.Encode()
+		r.failures.addTo(&d)
+		if len(t.Config.Ramp) > 0 {
+			d.RampStep = r.rampStep
+			d.Concurrency = cap(r.concurrency)
+			d.PayloadSize = len(r.buf)
+		}
+		d.PayloadMismatches = r.mismatches
+		d.CorruptedBytes = r.corrupted
+		if t.Config.Rate > 0 {
+			d.TargetRate = t.Config.Rate
+			d.Rate = float64(r.sent) / totalSecs
+			d.SendLag = r.sendLag
+		}
+		if c := t.peerClock(r.addr); c != nil {
+			c.collect(&d, created)
+		}
+		if t.Config.TestType == shared.MTUTest {
+			d.PathMTU = r.pathMTU
+			d.InterfaceMTU = r.interfaceMTU
+			d.KernelMTU = r.kernelMTU
+		}
+		r.sampleTCPInfo(&d)
+		if t.Config.Socket.IsSet() {
+			d.AcceptedSocket = t.acceptedSocket(r.ip)
+		}
+		if t.Config.TestType == shared.UDPTest && r.peer.IsValid() {
+			udpFlows.Get(t.ID, r.peer).collect(&d)
 		}
 
-		r.m.Lock()
 		r.hasStats = false
 		r.TTFBH = 0
 		r.TTFBL = math.MaxInt64
 		r.RMSH = 0
 		r.RMSL = math.MaxInt64
+		r.rmsHistogram.Reset()
+		r.ttfbHistogram.Reset()
+		r.phases = shared.RequestPhases{}
+		r.connectHistogram.Reset()
+		r.failures = connFailures{}
+		r.mismatches = 0
+		r.corrupted = 0
+		r.sent = 0
+		r.sendLag = 0
 		r.m.Unlock()
 
+		d.Local = localAddress()
+
 		t.DPS = append(t.DPS, d)
 	}
+	// the cores and interfaces are shared by every link,
+	// they are reported once per interval.
+	if len(cores) > 0 || len(nicStats) > 0 {
+		t.HostDPS = append(t.HostDPS, shared.HostDP{
+			TestID:  t.ID,
+			Local:   localAddress(),
+			Created: created,
+			Cores:   cores,
+			NICs:    nicStats,
+		})
+	}
+	if cpuStats != nil {
+		t.cpu = cpuStats
+	}
 	return
 }
 
-func newTransport(c *shared.Config) *http.Transport {
+func newTransport(c *shared.Config, tc *tls.Config, dial dialContext) *http.Transport {
 	return &http.Transport{
+		TLSClientConfig:       tc,
 		Proxy:                 http.ProxyFromEnvironment,
-		DialContext:           newDialContext(10 * time.Second),
+		DialContext:           dial,
 		MaxIdleConnsPerHost:   1024,
+		DisableKeepAlives:     c.TestType == shared.ChurnTest,
 		WriteBufferSize:       c.BufferSize,
 		ReadBufferSize:        c.BufferSize,
 		IdleConnTimeout:       15 * time.Second,
@@ -673,29 +1228,47 @@ func newTransport(c *shared.Config) *http.Transport {
 	}
 }
 
-func newDialContext(dialTimeout time.Duration) dialContext {
+func newDialContext(dialTimeout time.Duration, o shared.SocketOptions) dialContext {
 	d := &net.Dialer{
 		Timeout: dialTimeout,
-		Control: setTCPParametersFn(),
+		Control: setTCPParametersFn(o),
 	}
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return d.DialContext(ctx, network, addr)
+		con, err := d.DialContext(ctx, network, addr)
+		if err != nil || !o.Nagle {
+			return con, err
+		}
+		// Go enables TCP_NODELAY once the connection is established
+		if tc, ok := con.(*net.TCPConn); ok {
+			tc.SetNoDelay(false)
+		}
+		return con, nil
 	}
 }
 
 // DialContext is a function to make custom Dial for internode communications
 type dialContext func(ctx context.Context, network, address string) (net.Conn, error)
 
-func newPerformanceReaderForASingleHost(c shared.Config, host string, port string) (r *netPerfReader) {
+func newPerformanceReaderForASingleHost(c shared.Config, host string, port string, d shared.TestDirection, tc *tls.Config) (r *netPerfReader) {
 	r = new(netPerfReader)
 	r.lastDataPointTime = time.Now()
 	r.addr = net.JoinHostPort(host, port)
 	r.ip = host
+	r.direction = d
+	r.conns = make(map[*trackedConn]struct{})
+	r.socketOptions = c.Socket
 	r.buf = make([]byte, c.PayloadSize)
+	c.PayloadContent.Fill(r.buf)
+	if c.Verify {
+		r.pattern = shared.NewPayloadPattern(r.buf)
+	}
 	r.TTFBL = math.MaxInt64
 	r.RMSL = math.MaxInt64
+	r.rmsHistogram = shared.NewHistogram()
+	r.ttfbHistogram = shared.NewHistogram()
+	r.connectHistogram = shared.NewHistogram()
 	r.client = &http.Client{
-		Transport: newTransport(&c),
+		Transport: newTransport(&c, tc, newTrackedDialContext(r, newDialContext(10*time.Second, c.Socket))),
 	}
 	r.concurrency = make(chan int, c.Concurrency)
 	for i := 1; i <= c.Concurrency; i++ {
@@ -711,18 +1284,39 @@ func startPerformanceReader(t *test, r *netPerfReader) {
 			log.Println(r, string(debug.Stack()))
 		}
 	}()
+	if t.Config.ClockSync {
+		t.startClockSync(r.addr)
+	}
+	if t.Config.TestType == shared.UDPTest {
+		sendDatagrams(t, r)
+		return
+	}
+	if t.Config.TestType == shared.TCPTest {
+		startRawTCPReader(t, r)
+		return
+	}
+	if t.Config.TestType == shared.MTUTest {
+		probeMTU(t, r)
+		return
+	}
+	if t.Config.Rate > 0 {
+		startOpenLoopReader(t, r)
+		return
+	}
 	for {
 		var cid int
 		select {
 		case cid = <-r.concurrency:
-			go sendRequestToHost(t, r, cid)
-		case _ = <-t.ctx.Done():
+			go sendRequestToHost(t, r, cid, time.Time{})
+		case _ = <-r.ctx.Done():
 			return
 		}
 	}
 }
 
-func sendRequestToHost(t *test, r *netPerfReader, cid int) {
+// sendRequestToHost sends one request, the latency of requests with a
+// scheduled time is measured from that time instead of the actual send.
+func sendRequestToHost(t *test, r *netPerfReader, cid int, scheduled time.Time) {
 	defer func() {
 		rec := recover()
 		if rec != nil {
@@ -731,16 +1325,16 @@ func sendRequestToHost(t *test, r *netPerfReader, cid int) {
 		r.concurrency <- cid
 	}()
 
-	if t.Config.RequestDelay > 0 {
+	if t.Config.RequestDelay > 0 && scheduled.IsZero() {
 		time.Sleep(time.Duration(t.Config.RequestDelay) * time.Millisecond)
 	}
 
-	if t.ctx.Err() != nil {
+	if r.ctx.Err() != nil {
 		return
 	}
 
 	AR := new(asyncReader)
-	AR.ctx = t.ctx
+	AR.ctx = r.ctx
 	AR.pr = r
 	AR.c = &t.Config
 	AR.start = time.Now()
@@ -757,19 +1351,28 @@ func sendRequestToHost(t *test, r *netPerfReader, cid int) {
 	route := "/404"
 	var body io.Reader
 	method := http.MethodPut
+	download := r.direction == shared.DirectionDownload
 	switch t.Config.TestType {
 	case shared.StreamTest:
 		route = "/stream"
 		body = io.NopCloser(AR)
-	case shared.RequestTest:
+	case shared.RequestTest, shared.ChurnTest:
 		route = "/requests"
 		body = AR
 	default:
 		t.AddError(fmt.Errorf("Unknown test type: %d", t.Config.TestType), "unknown-signal")
 	}
 
+	if download {
+		method = http.MethodGet
+		body = nil
+		if t.Config.TestType != shared.StreamTest {
+			route += "?size=" + strconv.Itoa(len(r.buf))
+		}
+	}
+
 	req, err = http.NewRequestWithContext(
-		t.ctx,
+		httptrace.WithClientTrace(r.ctx, newClientTrace(r)),
 		method,
 		proto+r.addr+route,
 		body,
@@ -779,27 +1382,76 @@ func sendRequestToHost(t *test, r *netPerfReader, cid int) {
 		return
 	}
 
-	if t.Config.TestType == shared.StreamTest {
+	if t.Config.TestType == shared.StreamTest && !download {
 		req.ContentLength = -1
 	}
+	if r.pattern != nil {
+		setPatternHeaders(req, r.pattern)
+	} else if download {
+		req.Header.Set(shared.ContentHeader, t.Config.PayloadContent.String())
+	}
+	signPeerRequest(req, t)
 
 	sent := time.Now()
+	start := sent
+	if !scheduled.IsZero() {
+		start = scheduled
+		r.recordSend(sent.Sub(scheduled))
+	}
 	r.TXCount.Add(1)
 	resp, err = r.client.Do(req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return
 		}
+		r.countConnFailure(err)
 		t.AddError(err, "network-error")
 		return
 	}
 
+	if resp.StatusCode == http.StatusTooEarly {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if !t.peerStarting() {
+			t.AddError(fmt.Errorf("Test is not running on host %s", r.addr), "peer-not-running-"+r.addr)
+		}
+		time.Sleep(acceptTestRetry)
+		return
+	}
+
+	if resp.StatusCode == http.StatusUnprocessableEntity && r.pattern != nil {
+		corrupted, _ := strconv.ParseUint(resp.Header.Get(shared.MismatchHeader), 10, 64)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		r.countMismatch(t, corrupted)
+		return
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		t.AddError(fmt.Errorf("Status code was %d, expected 200 from host %s", resp.StatusCode, r.addr), "invalid-status-code")
 		return
 	}
 
-	done := time.Since(sent).Microseconds()
+	if download {
+		var v *shared.PayloadVerifier
+		if r.pattern != nil {
+			v = r.pattern.Verifier()
+		}
+		err = readResponseBody(t, r, resp.Body, sent, v)
+		resp.Body.Close()
+		if errors.Is(err, shared.ErrPayloadMismatch) {
+			r.countMismatch(t, v.Corrupted)
+			return
+		}
+		if err != nil {
+			if r.ctx.Err() == nil {
+				t.AddError(err, "network-error")
+			}
+			return
+		}
+	}
+
+	done := time.Since(start).Microseconds()
 
 	r.m.Lock()
 	if done > r.RMSH {
@@ -809,16 +1461,61 @@ func sendRequestToHost(t *test, r *netPerfReader, cid int) {
 	if done < r.RMSL {
 		r.RMSL = done
 	}
+	r.rmsHistogram.Record(done)
 	r.hasStats = true
 	r.m.Unlock()
 
-	io.Copy(io.Discard, resp.Body)
-	resp.Body.Close()
+	if !download {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
 
 	return
 }
 
-func listAllTests(con *websocket.Conn, s shared.WebsocketSignal) {
+// readResponseBody counts downloaded bytes the same way asyncReader
+// counts uploaded bytes, reading stops at the first corrupted chunk
+// when the payload is verified.
+func readResponseBody(t *test, r *netPerfReader, body io.Reader, sent time.Time, v *shared.PayloadVerifier) error {
+	buf := make([]byte, max(t.Config.BufferSize, 4096))
+	first := true
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if first {
+				first = false
+				r.registerTTFB(time.Since(sent).Microseconds())
+			} else {
+				r.m.Lock()
+				r.hasStats = true
+				r.m.Unlock()
+			}
+			r.TX.Add(uint64(n))
+			if v != nil {
+				_, verr := v.Write(buf[:n])
+				if verr != nil {
+					return verr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// zeroReader is an endless source of payload data for download tests
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+func listAllTests(con *wsConn, s shared.WebsocketSignal) {
 	defer SendDone(con)
 
 	var err error
@@ -836,7 +1533,7 @@ func listAllTests(con *websocket.Conn, s shared.WebsocketSignal) {
 	}
 }
 
-func getTestOnServer(con *websocket.Conn, s shared.WebsocketSignal) {
+func getTestOnServer(con *wsConn, s shared.WebsocketSignal) {
 	defer SendDone(con)
 	err := streamTestFilesToWebsocket(con, s.Config.TestID)
 	if err != nil {
@@ -844,7 +1541,7 @@ func getTestOnServer(con *websocket.Conn, s shared.WebsocketSignal) {
 	}
 }
 
-func sendAllDataPoints(con *websocket.Conn, t *test) error {
+func sendAllDataPoints(con *wsConn, t *test) error {
 	wss := new(shared.WebsocketSignal)
 	wss.SType = shared.Stats
 	dataResponse := new(shared.DataReponseToClient)
@@ -852,10 +1549,13 @@ func sendAllDataPoints(con *websocket.Conn, t *test) error {
 	for i := range t.DPS {
 		dataResponse.DPS = append(dataResponse.DPS, t.DPS[i])
 	}
+	dataResponse.Hosts = append(dataResponse.Hosts, t.HostDPS...)
 
+	t.M.Lock()
 	for i := range t.errors {
 		dataResponse.Errors = append(dataResponse.Errors, t.errors[i])
 	}
+	t.M.Unlock()
 
 	wss.DataPoint = dataResponse
 	err := con.WriteJSON(wss)
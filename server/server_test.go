@@ -0,0 +1,262 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/minio/hperf/shared"
+)
+
+var (
+	testServerOnce sync.Once
+	testServerAddr string
+	testServerErr  error
+)
+
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return port
+}
+
+// startTestServer runs one in-process server for all tests of the package,
+// the fiber app and the drain state are package globals.
+func startTestServer(t *testing.T) string {
+	testServerOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "hperf-test")
+		if err != nil {
+			testServerErr = err
+			return
+		}
+		testServerAddr = net.JoinHostPort("127.0.0.1", freePort(t))
+		go func() {
+			err := RunServer(context.Background(), Options{
+				Address:     testServerAddr,
+				StoragePath: dir,
+				TCPPort:     freePort(t),
+			})
+			if err != nil {
+				testServerErr = err
+			}
+		}()
+
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			con, err := dialTestServer(testServerAddr)
+			if err == nil {
+				con.Close()
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		testServerErr = context.DeadlineExceeded
+	})
+	if testServerErr != nil {
+		t.Fatal("Unable to start the server:", testServerErr)
+	}
+	return testServerAddr
+}
+
+// dialTestServer connects and reads the first Ping of the server
+func dialTestServer(addr string) (*websocket.Conn, error) {
+	con, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws/"+strconv.FormatInt(time.Now().UnixNano(), 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	ping := new(shared.WebsocketSignal)
+	err = con.ReadJSON(ping)
+	if err != nil {
+		con.Close()
+		return nil, err
+	}
+	return con, nil
+}
+
+func testSignal(st shared.SignalType, id string, port string) shared.WebsocketSignal {
+	return shared.WebsocketSignal{
+		SType:           st,
+		ProtocolVersion: shared.ProtocolVersion,
+		Config: shared.Config{
+			TestID:         id,
+			TestType:       shared.RequestTest,
+			Direction:      shared.DirectionUpload,
+			Duration:       2,
+			Concurrency:    1,
+			PayloadSize:    1000,
+			BufferSize:     1000,
+			RequestDelay:   100,
+			Port:           port,
+			Hosts:          []string{"127.0.0.1", "localhost"},
+			Insecure:       true,
+			Save:           true,
+			RestartOnError: true,
+		},
+	}
+}
+
+type signalResult struct {
+	errors []string
+	done   bool
+}
+
+// readUntilDone reads the replies to a signal until the server sends Done
+func readUntilDone(con *websocket.Conn, timeout time.Duration) (r signalResult) {
+	con.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		msg := new(shared.WebsocketSignal)
+		err := con.ReadJSON(msg)
+		if err != nil {
+			return
+		}
+		if msg.SType == shared.Err || msg.Error != "" {
+			r.errors = append(r.errors, msg.Error)
+		}
+		if msg.SType == shared.Done {
+			r.done = true
+			return
+		}
+	}
+}
+
+func runSignal(t *testing.T, addr string, s shared.WebsocketSignal) signalResult {
+	con, err := dialTestServer(addr)
+	if err != nil {
+		t.Error(err)
+		return signalResult{}
+	}
+	defer con.Close()
+	err = con.WriteJSON(s)
+	if err != nil {
+		t.Error(err)
+		return signalResult{}
+	}
+	return readUntilDone(con, 20*time.Second)
+}
+
+func TestOverlappingSignals(t *testing.T) {
+	addr := startTestServer(t)
+	_, port, _ := net.SplitHostPort(addr)
+
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	results := make(map[string][]signalResult)
+	run := func(name string, s shared.WebsocketSignal) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := runSignal(t, addr, s)
+			m.Lock()
+			results[name] = append(results[name], r)
+			m.Unlock()
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		id := "overlap-" + strconv.Itoa(i)
+		run(id, testSignal(shared.RunTest, id, port))
+	}
+	// only one of the tests with the same ID can run
+	run("duplicate", testSignal(shared.RunTest, "overlap-duplicate", port))
+	run("duplicate", testSignal(shared.RunTest, "overlap-duplicate", port))
+	run("stopped", testSignal(shared.RunTest, "overlap-stopped", port))
+
+	// listeners come and go while the tests are sending data points
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(i*200) * time.Millisecond)
+			con, err := dialTestServer(addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer con.Close()
+			err = con.WriteJSON(testSignal(shared.ListenTest, "", port))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			readUntilDone(con, time.Second)
+		}(i)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		runSignal(t, addr, testSignal(shared.StopAllTests, "overlap-stopped", port))
+	}()
+	go func() {
+		defer wg.Done()
+		runSignal(t, addr, testSignal(shared.TestStatus, "", port))
+	}()
+	wg.Wait()
+
+	for i := 0; i < 4; i++ {
+		id := "overlap-" + strconv.Itoa(i)
+		r := results[id][0]
+		if !r.done || len(r.errors) > 0 {
+			t.Errorf("%s: done %v, errors %v", id, r.done, r.errors)
+		}
+		tt, ok := tests.Get(id)
+		if !ok {
+			t.Errorf("%s is not registered", id)
+			continue
+		}
+		if st := tt.Status().State; st != shared.TestFinished {
+			t.Errorf("%s: state %s, expected %s", id, st, shared.TestFinished)
+		}
+	}
+
+	rejected := 0
+	for _, r := range results["duplicate"] {
+		if !r.done {
+			t.Error("duplicate test did not send Done")
+		}
+		for _, e := range r.errors {
+			if strings.Contains(e, "already running") {
+				rejected++
+			}
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("expected one duplicate test to be rejected, %d were", rejected)
+	}
+
+	tt, ok := tests.Get("overlap-stopped")
+	if !ok {
+		t.Fatal("overlap-stopped is not registered")
+	}
+	if st := tt.Status().State; st != shared.TestStopped {
+		t.Errorf("overlap-stopped: state %s, expected %s", st, shared.TestStopped)
+	}
+}
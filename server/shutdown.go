@@ -0,0 +1,95 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/hperf/shared"
+)
+
+var (
+	draining     atomic.Bool
+	drainOnce    sync.Once
+	runningTests sync.WaitGroup
+	// orders starting tests with the start of a drain, so
+	// runningTests.Wait never races with runningTests.Add
+	drainLock sync.Mutex
+
+	// cancels the context passed to startAPIandWS
+	stopServer context.CancelCauseFunc = func(error) {}
+)
+
+var errDraining = errors.New("Server is shutting down, not accepting new tests")
+
+// drainServer stops new tests from being created, then cancels or waits
+// for running tests. Once all tests have exited their result files are
+// closed and every listener receives a final Done with the reason.
+func drainServer(reason string, wait bool) {
+	drainLock.Lock()
+	if !draining.Swap(true) {
+		shared.INFO("Draining server:", reason)
+	}
+	drainLock.Unlock()
+
+	if !wait {
+		tests.Range(func(t *test) bool {
+			t.cancel(errors.New(reason))
+			return true
+		})
+	}
+
+	runningTests.Wait()
+
+	drainOnce.Do(func() {
+		tests.Range(func(t *test) bool {
+			finishTestFile(t)
+			for _, con := range t.Listeners() {
+				_ = SendDoneWithReason(con, reason)
+			}
+			return true
+		})
+		compressions.Wait()
+	})
+}
+
+// startRunningTest counts a new test unless the server is draining,
+// runningTests.Done has to be called when it returns true.
+func startRunningTest() bool {
+	drainLock.Lock()
+	defer drainLock.Unlock()
+	if draining.Load() {
+		return false
+	}
+	runningTests.Add(1)
+	return true
+}
+
+func shutdownFromSignal(con *wsConn, s shared.WebsocketSignal) {
+	reason := fmt.Sprintf("Shutdown requested by %s", con.RemoteAddr())
+	if s.Config.Debug {
+		fmt.Println(reason, "wait:", s.Config.DrainWait)
+	}
+	drainServer(reason, s.Config.DrainWait)
+	_ = SendDoneWithReason(con, reason)
+	stopServer(errors.New(reason))
+}
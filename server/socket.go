@@ -0,0 +1,123 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	// peers can connect a moment before the test started here, they are
+	// turned away and retry until the test is older than acceptTestWait.
+	acceptTestWait  = 2 * time.Second
+	acceptTestRetry = 100 * time.Millisecond
+)
+
+func validateSocketOptions(c shared.Config) error {
+	if !c.Socket.IsSet() {
+		return nil
+	}
+	switch c.TestType {
+	case shared.RequestTest, shared.StreamTest, shared.ChurnTest, shared.TCPTest:
+	default:
+		return fmt.Errorf("Socket options are only supported by HTTP and raw TCP tests")
+	}
+	err := c.Socket.Validate()
+	if err != nil {
+		return err
+	}
+	return checkSocketOptions(c.Socket)
+}
+
+// rawConn returns the socket of a connection, TLS connections are unwrapped
+func rawConn(con net.Conn) (syscall.RawConn, error) {
+	if tc, ok := con.(*tls.Conn); ok {
+		con = tc.NetConn()
+	}
+	sc, ok := con.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("Connection %s has no socket", con.RemoteAddr())
+	}
+	return sc.SyscallConn()
+}
+
+func isIPv6Conn(con net.Conn) bool {
+	addr, ok := con.LocalAddr().(*net.TCPAddr)
+	return ok && addr.IP.To4() == nil
+}
+
+// acceptTestSocket applies the socket options of the test named by the peer
+// to the connection of the request, once for every connection. It runs after
+// the peer was authenticated, a connection for a test which did not start
+// here yet is closed with 425 Too Early instead of waiting for it.
+func acceptTestSocket(c *fiber.Ctx) error {
+	id := c.Get(shared.TestHeader)
+	if id != "" && c.Context().ConnRequestNum() == 1 && !acceptSocket(id, c.Context().Conn()) {
+		c.Context().SetConnectionClose()
+		return c.SendStatus(http.StatusTooEarly)
+	}
+	return c.Next()
+}
+
+// acceptSocket applies the socket options of a test to a connection accepted
+// from one of its peers and keeps what the kernel applied for the results.
+// It reports false when the test is not known.
+func acceptSocket(id string, con net.Conn) bool {
+	t, ok := tests.Get(id)
+	if !ok {
+		return false
+	}
+	if !t.Config.Socket.IsSet() {
+		return true
+	}
+
+	peer, _, _ := net.SplitHostPort(con.RemoteAddr().String())
+	err := applySocketOptions(con, t.Config.Socket)
+	if err != nil {
+		t.AddError(fmt.Errorf("Unable to apply socket options to the connection from %s: %s", peer, err), "socket-options-"+peer)
+		return true
+	}
+	s, ok := readSocketState(con)
+	if !ok {
+		return true
+	}
+	t.socketsLock.Lock()
+	t.acceptedSockets[peer] = &s
+	t.socketsLock.Unlock()
+	return true
+}
+
+// peerStarting reports if peers may not have started the test yet
+func (t *test) peerStarting() bool {
+	return time.Since(t.Started) < acceptTestWait
+}
+
+// acceptedSocket is the state of the last connection accepted from peer
+func (t *test) acceptedSocket(peer string) *shared.SocketState {
+	t.socketsLock.Lock()
+	defer t.socketsLock.Unlock()
+	return t.acceptedSockets[peer]
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+var (
+	// finished tests are removed from memory after this period
+	testRetention = 1 * time.Hour
+
+	errTestStopped = errors.New("Client called StopAllTests")
+)
+
+func (t *test) setRunning() {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	t.state = shared.TestRunning
+	t.Started = time.Now()
+}
+
+// finish moves the test to a final state based on why the context
+// was canceled, a test which reached its duration is finished.
+func (t *test) finish() {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	if t.state.Done() {
+		return
+	}
+	t.ended = time.Now()
+
+	cause := context.Cause(t.ctx)
+	switch {
+	case cause == nil:
+		t.state = shared.TestFinished
+	case errors.Is(cause, errTestStopped), draining.Load():
+		t.state = shared.TestStopped
+		t.cause = cause.Error()
+	default:
+		t.state = shared.TestFailed
+		t.cause = cause.Error()
+	}
+}
+
+func (t *test) Status() shared.TestStatusReport {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	return shared.TestStatusReport{
+		ID:      t.ID,
+		Type:    t.Config.TestType,
+		State:   t.state,
+		Created: t.created,
+		Started: t.Started,
+		Ended:   t.ended,
+		Cause:   t.cause,
+	}
+}
+
+func (t *test) Metadata() shared.TestMetadata {
+	hostname, _ := os.Hostname()
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	return shared.TestMetadata{
+		TestID:          t.ID,
+		Server:          localAddress(),
+		Hostname:        hostname,
+		HperfVersion:    shared.Version,
+		ProtocolVersion: shared.ProtocolVersion,
+		Config:          t.Config,
+		Created:         t.created,
+		Started:         t.Started,
+		Ended:           t.ended,
+	}
+}
+
+func sendTestStatus(con *wsConn, s shared.WebsocketSignal) {
+	defer SendDone(con)
+
+	msg := new(shared.WebsocketSignal)
+	msg.SType = shared.TestStatus
+	msg.Code = shared.OK
+	msg.Statuses = make([]shared.TestStatusReport, 0)
+	for _, t := range tests.Filter(s.Config.TestID) {
+		msg.Statuses = append(msg.Statuses, t.Status())
+	}
+	err := con.WriteJSON(msg)
+	if err != nil {
+		shared.DEBUG("Unable to send test status:", err)
+	}
+}
+
+// evictFinishedTests removes tests which have been done for
+// longer than the retention period from the registry.
+func evictFinishedTests() {
+	tests.Range(func(t *test) bool {
+		st := t.Status()
+		if st.State.Done() && time.Since(st.Ended) > testRetention {
+			if tests.RemoveIf(t) {
+				shared.DEBUG("Evicting test:", t.ID)
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+// Raw TCP connections start with a single line before the payload:
+//
+//	HPERF-TCP/2 <timestamp> <signature> <test id>
+//
+// The timestamp and signature are the token of the test, the signature
+// is "-" when no auth key is configured. The test ID is query escaped.
+const (
+	tcpPreamble        = "HPERF-TCP/2"
+	tcpPreambleTimeout = 10 * time.Second
+	tcpReadBufferSize  = 1 << 20
+)
+
+var (
+	tcpAddress  = ""
+	tcpListener net.Listener
+
+	errInvalidPreamble = errors.New("Invalid raw TCP preamble")
+)
+
+// defaultTCPPort is the port next to the API port
+func defaultTCPPort(port string) (string, error) {
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("Invalid port (%s): %s", port, err)
+	}
+	return strconv.Itoa(p + 1), nil
+}
+
+// listenTCP accepts the connections of raw TCP tests and discards everything they send
+func listenTCP(ctx context.Context) (err error) {
+	tcpListener, err = net.Listen("tcp", tcpAddress)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		tcpListener.Close()
+	}()
+
+	go func() {
+		for {
+			con, err := tcpListener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				shared.DEBUG("Error accepting raw TCP connection:", err)
+				continue
+			}
+			go receiveRawTCP(con)
+		}
+	}()
+
+	return nil
+}
+
+func receiveRawTCP(con net.Conn) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			log.Println(r, string(debug.Stack()))
+		}
+		con.Close()
+	}()
+
+	br := bufio.NewReaderSize(con, tcpReadBufferSize)
+	con.SetReadDeadline(time.Now().Add(tcpPreambleTimeout))
+	id, err := readTCPPreamble(br)
+	if err != nil {
+		logRejected(con.RemoteAddr().String(), "tcp", err)
+		return
+	}
+	con.SetReadDeadline(time.Time{})
+	if !acceptSocket(id, con) {
+		return
+	}
+
+	buf := make([]byte, tcpReadBufferSize)
+	for {
+		_, err = br.Read(buf)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readTCPPreamble returns the test ID of the connection
+func readTCPPreamble(br *bufio.Reader) (id string, err error) {
+	line, err := br.ReadSlice('\n')
+	if err != nil {
+		return "", errInvalidPreamble
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) != 4 || fields[0] != tcpPreamble {
+		return "", errInvalidPreamble
+	}
+	id, err = url.QueryUnescape(fields[3])
+	if err != nil {
+		return "", errInvalidPreamble
+	}
+	if authKey == "" {
+		return id, nil
+	}
+	return id, shared.PeerAuth{
+		TestID:    id,
+		Timestamp: fields[1],
+	}.Verify(authKey, fields[2])
+}
+
+func writeTCPPreamble(con net.Conn, t *test) error {
+	ts := strconv.FormatInt(time.Now().UnixNano(), 10)
+	sig := "-"
+	if authKey != "" {
+		ts, sig = t.peerToken()
+	}
+	_, err := io.WriteString(con, tcpPreamble+" "+ts+" "+sig+" "+url.QueryEscape(t.ID)+"\n")
+	return err
+}
+
+func startRawTCPReader(t *test, r *netPerfReader) {
+	for i := 0; i < max(t.Config.Concurrency, 1); i++ {
+		go sendRawTCP(t, r)
+	}
+	<-t.ctx.Done()
+}
+
+// sendRawTCP writes the reader buffer to the peer until the test ends,
+// the connection is re-opened when it fails.
+func sendRawTCP(t *test, r *netPerfReader) {
+	defer func() {
+		rec := recover()
+		if rec != nil {
+			log.Println(rec, string(debug.Stack()))
+		}
+	}()
+
+	dial := newTrackedDialContext(r, newDialContext(10*time.Second, t.Config.Socket))
+	addr := net.JoinHostPort(r.ip, t.Config.TCPPort)
+	for t.ctx.Err() == nil {
+		con, err := dial(t.ctx, "tcp", addr)
+		if err != nil {
+			if t.ctx.Err() == nil {
+				t.AddError(err, "tcp-dial")
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		stop := context.AfterFunc(t.ctx, func() {
+			con.Close()
+		})
+
+		r.TXCount.Add(1)
+		err = writeTCPPreamble(con, t)
+		for err == nil {
+			var n int
+			n, err = con.Write(r.buf)
+			r.TX.Add(uint64(n))
+			r.m.Lock()
+			r.hasStats = true
+			r.m.Unlock()
+		}
+		stop()
+		con.Close()
+
+		if t.ctx.Err() != nil {
+			return
+		}
+		// the peer closes connections of tests it did not start yet
+		if t.peerStarting() {
+			time.Sleep(acceptTestRetry)
+			continue
+		}
+		t.AddError(fmt.Errorf("Raw TCP connection to %s failed: %s", addr, err), "tcp-write")
+		if !t.Config.RestartOnError {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
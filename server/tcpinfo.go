@@ -0,0 +1,138 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+
+	"github.com/minio/hperf/shared"
+)
+
+// tcpSample is the part of the kernel TCP_INFO we report, times are in microseconds
+type tcpSample struct {
+	RTT          uint32
+	RTTVar       uint32
+	TotalRetrans uint32
+	Lost         uint32
+	Cwnd         uint32
+	DeliveryRate uint64
+	PacingRate   uint64
+}
+
+// trackedConn is a test connection which is sampled every interval
+type trackedConn struct {
+	net.Conn
+	r         *netPerfReader
+	closeOnce sync.Once
+	// Total_retrans at the last sample
+	retrans uint32
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.r.untrackConn(c)
+	})
+	return c.Conn.Close()
+}
+
+// newTrackedDialContext registers every connection with the reader so
+// TCP_INFO can be sampled while the connection is open.
+func newTrackedDialContext(r *netPerfReader, dial dialContext) dialContext {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		con, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return r.trackConn(con), nil
+	}
+}
+
+func (r *netPerfReader) trackConn(con net.Conn) net.Conn {
+	tc := &trackedConn{Conn: con, r: r}
+	if s, ok := readTCPInfo(con); ok {
+		tc.retrans = s.TotalRetrans
+	}
+	var socket *shared.SocketState
+	if r.socketOptions.IsSet() {
+		if s, ok := readSocketState(con); ok {
+			socket = &s
+		}
+	}
+	r.connsLock.Lock()
+	r.conns[tc] = struct{}{}
+	if socket != nil {
+		r.socket = socket
+	}
+	r.connsLock.Unlock()
+	return tc
+}
+
+// untrackConn keeps the retransmits seen since the
+// last sample so they are not lost when a connection closes.
+func (r *netPerfReader) untrackConn(tc *trackedConn) {
+	s, ok := readTCPInfo(tc.Conn)
+	r.connsLock.Lock()
+	defer r.connsLock.Unlock()
+	if ok && s.TotalRetrans > tc.retrans {
+		r.closedRetrans += uint64(s.TotalRetrans - tc.retrans)
+	}
+	delete(r.conns, tc)
+}
+
+// sampleTCPInfo adds the kernel state of all open connections to the data point.
+// RTT, RTT variance and congestion window are averaged, the rest is summed.
+func (r *netPerfReader) sampleTCPInfo(d *shared.DP) {
+	r.connsLock.Lock()
+	defer r.connsLock.Unlock()
+
+	d.TCPRetransmits = r.closedRetrans
+	r.closedRetrans = 0
+	d.Socket = r.socket
+
+	var rtt, rttVar, cwnd uint64
+	for tc := range r.conns {
+		s, ok := readTCPInfo(tc.Conn)
+		if !ok {
+			continue
+		}
+		d.TCPConnections++
+		rtt += uint64(s.RTT)
+		rttVar += uint64(s.RTTVar)
+		cwnd += uint64(s.Cwnd)
+		d.TCPLost += uint64(s.Lost)
+		d.TCPDeliveryRate += s.DeliveryRate
+		// the kernel reports ~0 when pacing is not used
+		if s.PacingRate != math.MaxUint64 {
+			d.TCPPacingRate += s.PacingRate
+		}
+		if s.TotalRetrans > tc.retrans {
+			d.TCPRetransmits += uint64(s.TotalRetrans - tc.retrans)
+		}
+		tc.retrans = s.TotalRetrans
+	}
+
+	if d.TCPConnections > 0 {
+		n := uint64(d.TCPConnections)
+		d.TCPRTT = int64(rtt / n)
+		d.TCPRTTVar = int64(rttVar / n)
+		d.TCPCwnd = cwnd / n
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+// requestTrace times the phases of a single request, every phase is added
+// to the reader when it ends so long running stream requests report their
+// connection setup right away.
+type requestTrace struct {
+	r *netPerfReader
+
+	m            sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	gotConn      time.Time
+	wrote        time.Time
+}
+
+func newClientTrace(r *netPerfReader) *httptrace.ClientTrace {
+	rt := &requestTrace{r: r}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			rt.start(&rt.dnsStart)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			rt.done(&rt.dnsStart, info.Err, func(p *shared.RequestPhases) *shared.PhaseTiming { return &p.DNS })
+		},
+		ConnectStart: func(_, _ string) {
+			rt.start(&rt.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			us := rt.done(&rt.connectStart, err, func(p *shared.RequestPhases) *shared.PhaseTiming { return &p.Connect })
+			if us >= 0 {
+				rt.r.m.Lock()
+				rt.r.connectHistogram.Record(us)
+				rt.r.m.Unlock()
+			}
+		},
+		TLSHandshakeStart: func() {
+			rt.start(&rt.tlsStart)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			rt.done(&rt.tlsStart, err, func(p *shared.RequestPhases) *shared.PhaseTiming { return &p.TLS })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.start(&rt.gotConn)
+			rt.r.m.Lock()
+			if info.Reused {
+				rt.r.phases.ReusedConns++
+			} else {
+				rt.r.phases.NewConns++
+			}
+			rt.r.m.Unlock()
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			rt.done(&rt.gotConn, info.Err, func(p *shared.RequestPhases) *shared.PhaseTiming { return &p.Write })
+			rt.start(&rt.wrote)
+		},
+		GotFirstResponseByte: func() {
+			rt.done(&rt.wrote, nil, func(p *shared.RequestPhases) *shared.PhaseTiming { return &p.FirstByte })
+		},
+	}
+}
+
+func (rt *requestTrace) start(at *time.Time) {
+	rt.m.Lock()
+	*at = time.Now()
+	rt.m.Unlock()
+}
+
+// done records and returns the time since the phase started,
+// failed phases are not recorded and return -1.
+func (rt *requestTrace) done(started *time.Time, err error, phase func(*shared.RequestPhases) *shared.PhaseTiming) int64 {
+	rt.m.Lock()
+	since := time.Since(*started).Microseconds()
+	ok := !started.IsZero() && err == nil
+	rt.m.Unlock()
+	if !ok {
+		return -1
+	}
+
+	rt.r.m.Lock()
+	phase(&rt.r.phases).Record(since)
+	rt.r.hasStats = true
+	rt.r.m.Unlock()
+	return since
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/netip"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/minio/hperf/shared"
+)
+
+const (
+	defaultPacketRate = 1000
+	// how many sequence numbers back duplicates can be detected
+	udpWindowSize = 1024
+)
+
+var (
+	udpConn  *net.UDPConn
+	udpFlows = newFlowRegistry()
+)
+
+// udpFlow tracks the packets received from one peer during a test
+type udpFlow struct {
+	m sync.Mutex
+
+	started bool
+	first   uint64
+	highest uint64
+	unique  uint64
+	// sequence+1 of recently received packets, indexed by sequence%udpWindowSize
+	window [udpWindowSize]uint64
+
+	received     uint64
+	reordered    uint64
+	duplicate    uint64
+	reportedLost uint64
+
+	jitter      float64
+	lastTransit int64
+	hasTransit  bool
+}
+
+func (f *udpFlow) record(d shared.Datagram, arrived time.Time) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	slot := d.Sequence % udpWindowSize
+	if f.started && d.Sequence < f.first {
+		// sent before the first packet seen, which is where loss counting starts
+		f.received++
+		f.reordered++
+		return
+	}
+	if f.started && d.Sequence <= f.highest {
+		if f.highest-d.Sequence < udpWindowSize && f.window[slot] == d.Sequence+1 {
+			f.duplicate++
+			return
+		}
+		f.reordered++
+	} else {
+		if !f.started {
+			f.first = d.Sequence
+		}
+		f.started = true
+		f.highest = d.Sequence
+	}
+	f.window[slot] = d.Sequence + 1
+	f.unique++
+	f.received++
+
+	// RFC 3550 interarrival jitter, the clock offset between
+	// sender and receiver cancels out.
+	transit := arrived.UnixNano() - d.Sent
+	if f.hasTransit {
+		diff := math.Abs(float64(transit - f.lastTransit))
+		f.jitter += (diff - f.jitter) / 16
+	}
+	f.lastTransit = transit
+	f.hasTransit = true
+}
+
+// collect returns the values for the current interval and resets them.
+// Lost packets are the gaps in the sequence numbers seen so far, packets
+// sent before the receiver started the test are not counted.
+func (f *udpFlow) collect(d *shared.DP) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.started && f.highest-f.first+1 > f.unique {
+		lost := f.highest - f.first + 1 - f.unique
+		if lost > f.reportedLost {
+			d.PacketsLost = lost - f.reportedLost
+			f.reportedLost = lost
+		}
+	}
+	d.PacketsReceived = f.received
+	d.PacketsReordered = f.reordered
+	d.PacketsDuplicate = f.duplicate
+	d.Jitter = int64(f.jitter / 1000)
+
+	f.received = 0
+	f.reordered = 0
+	f.duplicate = 0
+}
+
+type flowRegistry struct {
+	m     sync.Mutex
+	flows map[string]map[netip.Addr]*udpFlow
+}
+
+func newFlowRegistry() *flowRegistry {
+	return &flowRegistry{
+		flows: make(map[string]map[netip.Addr]*udpFlow),
+	}
+}
+
+func (r *flowRegistry) Get(testID string, peer netip.Addr) *udpFlow {
+	r.m.Lock()
+	defer r.m.Unlock()
+	flows, ok := r.flows[testID]
+	if !ok {
+		flows = make(map[netip.Addr]*udpFlow)
+		r.flows[testID] = flows
+	}
+	f, ok := flows[peer]
+	if !ok {
+		f = new(udpFlow)
+		flows[peer] = f
+	}
+	return f
+}
+
+func (r *flowRegistry) Remove(testID string) {
+	r.m.Lock()
+	delete(r.flows, testID)
+	r.m.Unlock()
+}
+
+// listenUDP receives the datagrams of UDP tests, the probes of MTU tests
+// and clock probes on the same port as the API
+func listenUDP(ctx context.Context) (err error) {
+	addr, err := net.ResolveUDPAddr("udp", bindAddress)
+	if err != nil {
+		return err
+	}
+	udpConn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		udpConn.Close()
+	}()
+
+	go func() {
+		defer func() {
+			r := recover()
+			if r != nil {
+				log.Println(r, string(debug.Stack()))
+			}
+		}()
+		buf := make([]byte, shared.MaxDatagramSize)
+		for {
+			n, from, err := udpConn.ReadFromUDPAddrPort(buf)
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				shared.DEBUG("Error reading datagram:", err)
+				continue
+			}
+			arrived := time.Now()
+
+			if shared.IsMTUProbe(buf[:n]) {
+				answerMTUProbe(buf[:n], from)
+				continue
+			}
+			if shared.IsClockProbe(buf[:n]) {
+				answerClockProbe(buf[:n], from, arrived)
+				continue
+			}
+
+			d, err := shared.DecodeDatagram(buf[:n], authKey)
+			if err != nil {
+				logRejected(from.String(), "udp", err)
+				continue
+			}
+			// only keep track of tests which are running on this server
+			t, ok := tests.Get(d.TestID)
+			if !ok || t.ctx.Err() != nil {
+				continue
+			}
+			udpFlows.Get(d.TestID, from.Addr().Unmap()).record(d, arrived)
+		}
+	}()
+
+	return nil
+}
+
+// localUDPAddr makes sure datagrams leave from the address the server is bound to,
+// peers use the source address to match received packets to their own readers.
+func localUDPAddr() *net.UDPAddr {
+	host, _, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsUnspecified() {
+		return nil
+	}
+	return &net.UDPAddr{IP: ip}
+}
+
+func sendDatagrams(t *test, r *netPerfReader) {
+	defer func() {
+		rec := recover()
+		if rec != nil {
+			log.Println(rec, string(debug.Stack()))
+		}
+	}()
+
+	d := &net.Dialer{
+		LocalAddr: localUDPAddr(),
+		Timeout:   10 * time.Second,
+	}
+	con, err := d.DialContext(t.ctx, "udp", r.addr)
+	if err != nil {
+		t.AddError(err, "udp-dial")
+		return
+	}
+	defer con.Close()
+
+	r.m.Lock()
+	r.peer = con.RemoteAddr().(*net.UDPAddr).AddrPort().Addr().Unmap()
+	r.m.Unlock()
+
+	rate := t.Config.PacketRate
+	if rate <= 0 {
+		rate = defaultPacketRate
+	}
+	interval := time.Second / time.Duration(rate)
+
+	size := min(max(t.Config.PayloadSize, shared.DatagramHeaderSize(t.ID)), shared.MaxDatagramSize)
+	buf := make([]byte, size)
+	t.Config.PayloadContent.Fill(buf)
+	dg := shared.Datagram{TestID: t.ID}
+
+	start := time.Now()
+	for t.ctx.Err() == nil {
+		// send everything that is due, packets are sent in bursts
+		// when the rate is higher than what time.Sleep can resolve.
+		due := uint64(time.Since(start) / interval)
+		for ; dg.Sequence <= due; dg.Sequence++ {
+			dg.Sent = time.Now().UnixNano()
+			_, err = dg.Encode(buf, authKey)
+			if err != nil {
+				t.AddError(err, "udp-encode")
+				return
+			}
+			_, err = con.Write(buf)
+			if err != nil {
+				t.AddError(fmt.Errorf("Unable to send datagram to %s: %s", r.addr, err), "udp-write")
+				continue
+			}
+			r.TX.Add(uint64(len(buf)))
+			r.TXCount.Add(1)
+		}
+
+		r.m.Lock()
+		r.hasStats = true
+		r.m.Unlock()
+
+		time.Sleep(time.Until(start.Add(time.Duration(dg.Sequence) * interval)))
+	}
+}
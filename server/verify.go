@@ -0,0 +1,110 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/hperf/shared"
+)
+
+// every reader of a peer sends the same pattern with each request,
+// the patterns are cached so they are not generated for every one.
+const maxCachedPatterns = 64
+
+var (
+	patternsLock sync.Mutex
+	patterns     = make(map[string]*shared.PayloadPattern)
+)
+
+// requestPattern returns the payload pattern announced by the peer,
+// it is nil when the payload of the request is not verified.
+func requestPattern(c *fiber.Ctx) (*shared.PayloadPattern, error) {
+	h := c.Get(shared.PatternHeader)
+	if h == "" {
+		return nil, nil
+	}
+	checksum := c.Get(shared.ChecksumHeader)
+
+	patternsLock.Lock()
+	p, ok := patterns[h]
+	patternsLock.Unlock()
+	if ok {
+		if p.Checksum() != checksum {
+			return nil, fmt.Errorf("payload pattern checksum %q does not match %q", checksum, p.Checksum())
+		}
+		return p, nil
+	}
+
+	p, err := shared.ParsePayloadPattern(h, checksum)
+	if err != nil {
+		return nil, err
+	}
+	patternsLock.Lock()
+	if len(patterns) >= maxCachedPatterns {
+		clear(patterns)
+	}
+	patterns[h] = p
+	patternsLock.Unlock()
+	return p, nil
+}
+
+// verifyUpload compares the uploaded body with the pattern, the peer is told
+// how many bytes were corrupted with a 422 status. Streams are not read any
+// further once a corrupted chunk was found.
+func verifyUpload(c *fiber.Ctx, p *shared.PayloadPattern, body io.Reader) error {
+	v := p.Verifier()
+	io.Copy(v, body)
+	if v.Corrupted == 0 {
+		return c.SendStatus(http.StatusOK)
+	}
+	c.Set(shared.MismatchHeader, strconv.FormatUint(v.Corrupted, 10))
+	return c.SendStatus(http.StatusUnprocessableEntity)
+}
+
+// sendPattern answers a download with the pattern the peer asked for
+func sendPattern(c *fiber.Ctx, p *shared.PayloadPattern, size int) {
+	c.Set(shared.PatternHeader, p.Header())
+	c.Set(shared.ChecksumHeader, p.Checksum())
+	if size < 0 {
+		c.Response().SetBodyStream(p.Reader(), -1)
+		return
+	}
+	c.Response().SetBodyStream(io.LimitReader(p.Reader(), int64(size)), size)
+}
+
+func setPatternHeaders(req *http.Request, p *shared.PayloadPattern) {
+	req.Header.Set(shared.PatternHeader, p.Header())
+	req.Header.Set(shared.ChecksumHeader, p.Checksum())
+}
+
+// countMismatch records a payload which did not match the pattern
+func (r *netPerfReader) countMismatch(t *test, corrupted uint64) {
+	r.m.Lock()
+	r.mismatches++
+	r.corrupted += corrupted
+	r.hasStats = true
+	r.m.Unlock()
+
+	t.AddError(fmt.Errorf("Payload between %s and %s was corrupted, %d bytes did not match", localAddress(), r.addr, corrupted), "payload-mismatch-"+r.addr)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	AuthTimestampHeader = "X-Hperf-Timestamp"
+	AuthSignatureHeader = "X-Hperf-Signature"
+
+	// AuthMaxSkew is how far the timestamp of a signed message can be
+	// from the local clock before it is rejected.
+	AuthMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.New("Missing signature")
+	ErrInvalidSignature = errors.New("Invalid signature")
+	ErrExpiredSignature = errors.New("Signature timestamp is outside the allowed window")
+)
+
+// SignSignal adds a timestamp, a random nonce and a HMAC-SHA256 signature
+// to the signal. Nothing is done if key is empty.
+func SignSignal(s *WebsocketSignal, key string) error {
+	if key == "" {
+		return nil
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	s.Timestamp = time.Now().UnixNano()
+	s.Nonce = nonce
+	s.Signature, err = signalSignature(s, key)
+	return err
+}
+
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// VerifySignal checks the signature and timestamp of a signal, replays
+// have to be detected by the caller using the nonce.
+func VerifySignal(s *WebsocketSignal, key string) error {
+	if s.Signature == "" || s.Nonce == "" {
+		return ErrMissingSignature
+	}
+	if !withinSkew(time.Unix(0, s.Timestamp)) {
+		return ErrExpiredSignature
+	}
+	expected, err := signalSignature(s, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(s.Signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signalSignature(s *WebsocketSignal, key string) (string, error) {
+	cb, err := json.Marshal(s.Config)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strconv.Itoa(int(s.SType))))
+	mac.Write([]byte{10})
+	mac.Write([]byte(strconv.Itoa(s.ProtocolVersion)))
+	mac.Write([]byte{10})
+	mac.Write([]byte(strconv.FormatInt(s.Timestamp, 10)))
+	mac.Write([]byte{10})
+	mac.Write([]byte(s.Nonce))
+	mac.Write([]byte{10})
+	mac.Write(cb)
+	mac.Write([]byte{10})
+	mac.Write(s.Data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// PeerAuth is the token servers use to authenticate against each other on
+// the test endpoints. It is signed once per test and renewed before it
+// leaves the allowed clock skew, so it only authorizes traffic of that
+// test and checking it costs no memory per request.
+type PeerAuth struct {
+	TestID    string
+	Timestamp string
+}
+
+// NewPeerAuth creates the token of a test
+func NewPeerAuth(testID string) PeerAuth {
+	return PeerAuth{
+		TestID:    testID,
+		Timestamp: strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+}
+
+func (p PeerAuth) Signature(key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("peer"))
+	for _, v := range []string{p.TestID, p.Timestamp} {
+		mac.Write([]byte{10})
+		mac.Write([]byte(v))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and the timestamp of a token
+func (p PeerAuth) Verify(key string, signature string) error {
+	if p.Timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(p.Timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !withinSkew(time.Unix(0, ts)) {
+		return ErrExpiredSignature
+	}
+	if !hmac.Equal([]byte(p.Signature(key)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func withinSkew(t time.Time) bool {
+	d := time.Since(t)
+	if d < 0 {
+		d = -d
+	}
+	return d <= AuthMaxSkew
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPeerAuth(t *testing.T) {
+	p := NewPeerAuth("test-1")
+	sig := p.Signature("key")
+	if err := p.Verify("key", sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Verify("other", sig); err != ErrInvalidSignature {
+		t.Errorf("wrong key: expected %v, got %v", ErrInvalidSignature, err)
+	}
+	other := PeerAuth{TestID: "test-2", Timestamp: p.Timestamp}
+	if err := other.Verify("key", sig); err != ErrInvalidSignature {
+		t.Errorf("other test: expected %v, got %v", ErrInvalidSignature, err)
+	}
+	if err := p.Verify("key", ""); err != ErrMissingSignature {
+		t.Errorf("missing signature: expected %v, got %v", ErrMissingSignature, err)
+	}
+
+	old := PeerAuth{TestID: "test-1", Timestamp: strconv.FormatInt(time.Now().Add(-AuthMaxSkew-time.Minute).UnixNano(), 10)}
+	if err := old.Verify("key", old.Signature("key")); err != ErrExpiredSignature {
+		t.Errorf("expired token: expected %v, got %v", ErrExpiredSignature, err)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"slices"
+	"time"
+)
+
+// ClockSample is one NTP style exchange with a peer, all times are unix
+// nanoseconds. T1 and T4 are read from the local clock when the probe is
+// sent and the answer arrives, T2 and T3 from the peer clock when the
+// probe arrives and the answer is sent.
+type ClockSample struct {
+	T1 int64
+	T2 int64
+	T3 int64
+	T4 int64
+}
+
+// Offset is how far the peer clock is ahead of the local clock,
+// assuming the network delay is the same in both directions.
+func (s ClockSample) Offset() int64 {
+	return ((s.T2 - s.T1) + (s.T3 - s.T4)) / 2
+}
+
+// Delay is the round trip time without the time spent on the peer
+func (s ClockSample) Delay() int64 {
+	return (s.T4 - s.T1) - (s.T3 - s.T2)
+}
+
+// OneWay returns the delay to the peer and back once the clocks
+// are aligned with the given offset.
+func (s ClockSample) OneWay(offset int64) (out int64, back int64) {
+	return s.T2 - s.T1 - offset, s.T4 - s.T3 + offset
+}
+
+const (
+	maxClockSamples = 512
+	// samples up to this much slower than the fastest one are used
+	clockDelaySlack = 50_000
+)
+
+// ClockModel is the offset of a peer clock at Ref and how fast it
+// drifts away, in nanoseconds per nanosecond.
+type ClockModel struct {
+	Ref    int64
+	Offset float64
+	Drift  float64
+}
+
+// At returns the offset of the peer clock at local time t
+func (m ClockModel) At(t int64) int64 {
+	return int64(m.Offset + m.Drift*float64(t-m.Ref))
+}
+
+// DriftPPM is the drift in parts per million
+func (m ClockModel) DriftPPM() float64 {
+	return m.Drift * 1e6
+}
+
+// ClockEstimator keeps the recent samples of one peer. Queueing makes the
+// delay asymmetric, so only the samples with the lowest delay are used to
+// estimate the offset and the drift.
+type ClockEstimator struct {
+	samples []ClockSample
+}
+
+func (e *ClockEstimator) Add(s ClockSample) {
+	if len(e.samples) == maxClockSamples {
+		e.samples = slices.Delete(e.samples, 0, 1)
+	}
+	e.samples = append(e.samples, s)
+}
+
+func (e *ClockEstimator) Len() int {
+	return len(e.samples)
+}
+
+// Estimate fits a line through the offsets of the fastest samples,
+// the drift stays 0 until the samples span at least a second.
+func (e *ClockEstimator) Estimate() (m ClockModel, ok bool) {
+	if len(e.samples) == 0 {
+		return m, false
+	}
+	minDelay := e.samples[0].Delay()
+	for _, s := range e.samples {
+		minDelay = min(minDelay, s.Delay())
+	}
+	limit := minDelay + max(minDelay/2, clockDelaySlack)
+
+	var n, sumX, sumY, sumXX, sumXY float64
+	first, last := int64(0), int64(0)
+	for _, s := range e.samples {
+		if s.Delay() > limit {
+			continue
+		}
+		if n == 0 {
+			first = s.T1
+		}
+		last = s.T1
+		x := float64(s.T1 - first)
+		y := float64(s.Offset())
+		n++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+
+	m.Ref = first
+	m.Offset = sumY / n
+	if n >= 2 && last-first >= 1e9 {
+		m.Drift = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+		m.Offset = (sumY - m.Drift*sumX) / n
+	}
+	return m, true
+}
+
+// ClockProbe is a datagram of the clock exchange between servers, the peer
+// answers with the same probe after setting T2 and T3.
+//
+// Layout: magic(4) version(1) reply(1) id length(1) id sequence(8) t1(8) t2(8) t3(8) mac(16)
+type ClockProbe struct {
+	TestID   string
+	Sequence uint64
+	Reply    bool
+	T1       int64
+	T2       int64
+	T3       int64
+}
+
+const clockProbeVersion = 1
+
+var clockProbeMagic = []byte("HPCK")
+
+func ClockProbeSize(testID string) int {
+	return len(clockProbeMagic) + 3 + len(testID) + 32 + datagramMACSize
+}
+
+// IsClockProbe reports if b starts like a clock probe
+func IsClockProbe(b []byte) bool {
+	return len(b) >= len(clockProbeMagic) && string(b[:len(clockProbeMagic)]) == string(clockProbeMagic)
+}
+
+// Encode writes the probe to b and returns its size.
+// The MAC is only set when key is not empty.
+func (p *ClockProbe) Encode(b []byte, key string) (int, error) {
+	size := ClockProbeSize(p.TestID)
+	if len(p.TestID) > 255 || len(b) < size {
+		return 0, ErrInvalidDatagram
+	}
+	n := copy(b, clockProbeMagic)
+	b[n] = clockProbeVersion
+	b[n+1] = 0
+	if p.Reply {
+		b[n+1] = 1
+	}
+	b[n+2] = byte(len(p.TestID))
+	n += 3
+	n += copy(b[n:], p.TestID)
+	binary.BigEndian.PutUint64(b[n:], p.Sequence)
+	binary.BigEndian.PutUint64(b[n+8:], uint64(p.T1))
+	binary.BigEndian.PutUint64(b[n+16:], uint64(p.T2))
+	binary.BigEndian.PutUint64(b[n+24:], uint64(p.T3))
+	n += 32
+	if key != "" {
+		copy(b[n:n+datagramMACSize], datagramMAC(b[:n], key))
+	} else {
+		clear(b[n : n+datagramMACSize])
+	}
+	return size, nil
+}
+
+// DecodeClockProbe parses a clock probe, the MAC is verified when key is not empty.
+func DecodeClockProbe(b []byte, key string) (p ClockProbe, err error) {
+	if !IsClockProbe(b) || len(b) < len(clockProbeMagic)+3 {
+		return p, ErrInvalidDatagram
+	}
+	n := len(clockProbeMagic)
+	if b[n] != clockProbeVersion {
+		return p, ErrInvalidDatagram
+	}
+	p.Reply = b[n+1] == 1
+	idLen := int(b[n+2])
+	n += 3
+	if len(b) < n+idLen+32+datagramMACSize {
+		return p, ErrInvalidDatagram
+	}
+	p.TestID = string(b[n : n+idLen])
+	n += idLen
+	p.Sequence = binary.BigEndian.Uint64(b[n:])
+	p.T1 = int64(binary.BigEndian.Uint64(b[n+8:]))
+	p.T2 = int64(binary.BigEndian.Uint64(b[n+16:]))
+	p.T3 = int64(binary.BigEndian.Uint64(b[n+24:]))
+	n += 32
+	if key != "" && !hmac.Equal(b[n:n+datagramMACSize], datagramMAC(b[:n], key)) {
+		return p, ErrInvalidSignature
+	}
+	if key != "" && !withinSkew(time.Unix(0, p.T1)) {
+		return p, ErrExpiredSignature
+	}
+	return p, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PayloadContent is what test payloads are filled with. Zeroes are cheap
+// but WAN optimizers, compressing VPNs and dedup appliances shrink them to
+// almost nothing. Random data can not be compressed and "ratio:N" data
+// compresses to about 1/N of its size. Verified payloads are filled with
+// the seeded pattern of PayloadPattern.
+type PayloadContent string
+
+const (
+	ContentZero    PayloadContent = "zero"
+	ContentRandom  PayloadContent = "random"
+	ContentPattern PayloadContent = "pattern"
+
+	contentRatioPrefix = "ratio:"
+	// every chunk starts with random bytes followed by zeroes,
+	// the share of random bytes sets the compression ratio.
+	contentChunkSize = 4096
+	maxContentRatio  = 1000
+
+	// ContentHeader tells the peer what to fill download payloads with
+	ContentHeader = "X-Hperf-Content"
+)
+
+func ParsePayloadContent(s string) (PayloadContent, error) {
+	switch PayloadContent(s) {
+	case "":
+		return ContentZero, nil
+	case ContentZero, ContentRandom, ContentPattern:
+		return PayloadContent(s), nil
+	}
+	ratio, ok := strings.CutPrefix(s, contentRatioPrefix)
+	if ok {
+		r, err := strconv.ParseFloat(ratio, 64)
+		if err == nil && r >= 1 && r <= maxContentRatio {
+			return PayloadContent(contentRatioPrefix + strconv.FormatFloat(r, 'f', -1, 64)), nil
+		}
+	}
+	return "", fmt.Errorf("Unknown payload content (%s), expected one of: %s, %s, %s, %sN with N between 1 and %d", s, ContentZero, ContentRandom, ContentPattern, contentRatioPrefix, maxContentRatio)
+}
+
+// Ratio is the compression ratio the content is built for,
+// zeroes have no meaningful ratio and return 0.
+func (c PayloadContent) Ratio() float64 {
+	switch c {
+	case "", ContentZero:
+		return 0
+	case ContentRandom, ContentPattern:
+		return 1
+	}
+	r, _ := strconv.ParseFloat(strings.TrimPrefix(string(c), contentRatioPrefix), 64)
+	return r
+}
+
+// Fill overwrites b with the content
+func (c PayloadContent) Fill(b []byte) {
+	if c == ContentPattern {
+		NewPayloadPattern(b)
+		return
+	}
+	ratio := c.Ratio()
+	if ratio == 0 {
+		clear(b)
+		return
+	}
+	random := int(contentChunkSize/ratio + 0.5)
+	for i := 0; i < len(b); i += contentChunkSize {
+		chunk := b[i:min(i+contentChunkSize, len(b))]
+		n := min(random, len(chunk))
+		rand.Read(chunk[:n])
+		clear(chunk[n:])
+	}
+}
+
+func (c PayloadContent) String() string {
+	if c == "" {
+		return string(ContentZero)
+	}
+	return string(c)
+}
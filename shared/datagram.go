@@ -0,0 +1,116 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	datagramVersion = 1
+	datagramMACSize = 16
+
+	// MaxDatagramSize is the largest UDP payload that fits in an IPv4 packet
+	MaxDatagramSize = 65507
+	// MaxPacketRate keeps the send interval of UDP tests above a microsecond
+	MaxPacketRate = 1_000_000
+)
+
+var (
+	datagramMagic = []byte("HPRF")
+
+	ErrInvalidDatagram = errors.New("Invalid datagram")
+)
+
+// Datagram is the header of every packet sent during a UDP test, the
+// rest of the packet is padding up to the configured packet size.
+//
+// Layout: magic(4) version(1) id length(1) id sequence(8) sent(8) mac(16)
+type Datagram struct {
+	TestID   string
+	Sequence uint64
+	// Sent is the sender clock in unix nanoseconds
+	Sent int64
+}
+
+func DatagramHeaderSize(testID string) int {
+	return len(datagramMagic) + 2 + len(testID) + 16 + datagramMACSize
+}
+
+// Encode writes the header to the start of b and returns the header size.
+// The MAC is only set when key is not empty.
+func (d *Datagram) Encode(b []byte, key string) (int, error) {
+	size := DatagramHeaderSize(d.TestID)
+	if len(d.TestID) > 255 || len(b) < size {
+		return 0, ErrInvalidDatagram
+	}
+	n := copy(b, datagramMagic)
+	b[n] = datagramVersion
+	b[n+1] = byte(len(d.TestID))
+	n += 2
+	n += copy(b[n:], d.TestID)
+	binary.BigEndian.PutUint64(b[n:], d.Sequence)
+	binary.BigEndian.PutUint64(b[n+8:], uint64(d.Sent))
+	n += 16
+	if key != "" {
+		copy(b[n:n+datagramMACSize], datagramMAC(b[:n], key))
+	} else {
+		clear(b[n : n+datagramMACSize])
+	}
+	return size, nil
+}
+
+// DecodeDatagram parses the header of a packet, the MAC is
+// verified when key is not empty.
+func DecodeDatagram(b []byte, key string) (d Datagram, err error) {
+	if len(b) < len(datagramMagic)+2 || string(b[:len(datagramMagic)]) != string(datagramMagic) {
+		return d, ErrInvalidDatagram
+	}
+	n := len(datagramMagic)
+	if b[n] != datagramVersion {
+		return d, ErrInvalidDatagram
+	}
+	idLen := int(b[n+1])
+	n += 2
+	if len(b) < n+idLen+16+datagramMACSize {
+		return d, ErrInvalidDatagram
+	}
+	d.TestID = string(b[n : n+idLen])
+	n += idLen
+	d.Sequence = binary.BigEndian.Uint64(b[n:])
+	d.Sent = int64(binary.BigEndian.Uint64(b[n+8:]))
+	n += 16
+	if key != "" && !hmac.Equal(b[n:n+datagramMACSize], datagramMAC(b[:n], key)) {
+		return d, ErrInvalidSignature
+	}
+	// replays within the window are counted as duplicates by the receiver
+	if key != "" && !withinSkew(time.Unix(0, d.Sent)) {
+		return d, ErrExpiredSignature
+	}
+	return d, nil
+}
+
+func datagramMAC(header []byte, key string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(header)
+	return mac.Sum(nil)[:datagramMACSize]
+}
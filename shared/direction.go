@@ -0,0 +1,62 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import "fmt"
+
+// TestDirection is the direction data flows between the server running
+// the test and its peers. Upload sends data to the peer, download
+// fetches data from the peer.
+type TestDirection string
+
+const (
+	DirectionUpload   TestDirection = "upload"
+	DirectionDownload TestDirection = "download"
+	DirectionBoth     TestDirection = "both"
+)
+
+func ParseDirection(s string) (TestDirection, error) {
+	switch TestDirection(s) {
+	case "":
+		return DirectionUpload, nil
+	case DirectionUpload, DirectionDownload, DirectionBoth:
+		return TestDirection(s), nil
+	default:
+		return "", fmt.Errorf("Unknown direction (%s), expected one of: %s, %s, %s", s, DirectionUpload, DirectionDownload, DirectionBoth)
+	}
+}
+
+// Directions expands DirectionBoth, an empty direction means upload
+// which is what servers without direction support do.
+func (d TestDirection) Directions() []TestDirection {
+	switch d {
+	case DirectionBoth:
+		return []TestDirection{DirectionUpload, DirectionDownload}
+	case "":
+		return []TestDirection{DirectionUpload}
+	default:
+		return []TestDirection{d}
+	}
+}
+
+func (d TestDirection) String() string {
+	if d == "" {
+		return string(DirectionUpload)
+	}
+	return string(d)
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+const (
+	// values below histogramSubBuckets are recorded exactly, larger values
+	// are recorded with a relative error of at most 1/(histogramSubBuckets/2)
+	histogramSubBucketBits = 7
+	histogramSubBuckets    = 1 << histogramSubBucketBits
+	histogramHalfBuckets   = histogramSubBuckets / 2
+
+	histogramEncodingVersion = 1
+)
+
+var ErrInvalidHistogram = errors.New("Invalid histogram encoding")
+
+// Histogram is a log-linear (HDR style) histogram of non-negative values.
+// Histograms with the same layout can be merged without losing precision,
+// which makes it possible to compute percentiles across links and time.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	min    int64
+	max    int64
+}
+
+// EncodedHistogram is the compact form of a Histogram stored in data points
+type EncodedHistogram []byte
+
+func (e EncodedHistogram) String() string {
+	return base64.StdEncoding.EncodeToString(e)
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{min: math.MaxInt64}
+}
+
+func histogramIndex(v int64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histogramSubBucketBits
+	return shift*histogramHalfBuckets + int(v>>shift)
+}
+
+// histogramBounds returns the lowest and highest value recorded in the bucket
+func histogramBounds(index int) (low int64, high int64) {
+	if index < histogramSubBuckets {
+		return int64(index), int64(index)
+	}
+	shift := index/histogramHalfBuckets - 1
+	mantissa := int64(index - shift*histogramHalfBuckets)
+	return mantissa << shift, (mantissa+1)<<shift - 1
+}
+
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	i := histogramIndex(v)
+	if i >= len(h.counts) {
+		h.counts = append(h.counts, make([]uint64, i+1-len(h.counts))...)
+	}
+	h.counts[i]++
+	h.total++
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.total == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		h.counts = append(h.counts, make([]uint64, len(o.counts)-len(h.counts))...)
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	if o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+func (h *Histogram) Reset() {
+	h.counts = h.counts[:0]
+	h.total = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+func (h *Histogram) Min() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.min
+}
+
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+func (h *Histogram) Mean() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	var sum float64
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		low, high := histogramBounds(i)
+		sum += float64(c) * float64(low+high) / 2
+	}
+	return int64(sum / float64(h.total))
+}
+
+// Percentile returns the value below which p percent of all
+// recorded values fall, p is in the range 0-100.
+func (h *Histogram) Percentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		low, high := histogramBounds(i)
+		v := low + (high-low)/2
+		if v < h.min {
+			v = h.min
+		}
+		if v > h.max {
+			v = h.max
+		}
+		return v
+	}
+	return h.max
+}
+
+// Encode returns the histogram as a list of uvarints: the encoding version,
+// min, max and then pairs of (bucket index delta, count) for every
+// bucket that has values.
+func (h *Histogram) Encode() EncodedHistogram {
+	if h.total == 0 {
+		return nil
+	}
+	b := make([]byte, 0, 32)
+	b = binary.AppendUvarint(b, histogramEncodingVersion)
+	b = binary.AppendUvarint(b, uint64(h.min))
+	b = binary.AppendUvarint(b, uint64(h.max))
+	prev := 0
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		b = binary.AppendUvarint(b, uint64(i-prev))
+		b = binary.AppendUvarint(b, c)
+		prev = i
+	}
+	return b
+}
+
+func DecodeHistogram(e EncodedHistogram) (h *Histogram, err error) {
+	h = NewHistogram()
+	if len(e) == 0 {
+		return
+	}
+
+	values := make([]uint64, 0, len(e))
+	for len(e) > 0 {
+		v, n := binary.Uvarint(e)
+		if n <= 0 {
+			return nil, ErrInvalidHistogram
+		}
+		values = append(values, v)
+		e = e[n:]
+	}
+	if len(values) < 3 || values[0] != histogramEncodingVersion || (len(values)-3)%2 != 0 {
+		return nil, ErrInvalidHistogram
+	}
+
+	h.min = int64(values[1])
+	h.max = int64(values[2])
+	// the deltas are checked before they are added, a large
+	// delta would otherwise wrap the index around.
+	index := 0
+	maxIndex := histogramIndex(math.MaxInt64)
+	for i := 3; i < len(values); i += 2 {
+		if values[i] > uint64(maxIndex-index) {
+			return nil, ErrInvalidHistogram
+		}
+		index += int(values[i])
+		if index < 0 || index > maxIndex {
+			return nil, ErrInvalidHistogram
+		}
+		if index >= len(h.counts) {
+			h.counts = append(h.counts, make([]uint64, index+1-len(h.counts))...)
+		}
+		h.counts[index] += values[i+1]
+		h.total += values[i+1]
+	}
+	return
+}
+
+// MergeHistograms decodes and merges the given histograms,
+// invalid encodings are skipped.
+func MergeHistograms(list ...EncodedHistogram) *Histogram {
+	h := NewHistogram()
+	for _, e := range list {
+		d, err := DecodeHistogram(e)
+		if err != nil {
+			continue
+		}
+		h.Merge(d)
+	}
+	return h
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func encodeValues(values ...uint64) EncodedHistogram {
+	var e []byte
+	for _, v := range values {
+		e = binary.AppendUvarint(e, v)
+	}
+	return e
+}
+
+func TestHistogramRoundTrip(t *testing.T) {
+	h := NewHistogram()
+	for _, v := range []int64{0, 1, 100, 5000, 5000, 1 << 40, math.MaxInt64} {
+		h.Record(v)
+	}
+	d, err := DecodeHistogram(h.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Count() != h.Count() || d.Min() != h.Min() || d.Max() != h.Max() {
+		t.Errorf("decoded histogram differs: count %d/%d min %d/%d max %d/%d",
+			d.Count(), h.Count(), d.Min(), h.Min(), d.Max(), h.Max())
+	}
+	if d.Percentile(50) != h.Percentile(50) {
+		t.Errorf("p50 differs: %d/%d", d.Percentile(50), h.Percentile(50))
+	}
+}
+
+func TestDecodeHistogramInvalidIndex(t *testing.T) {
+	maxIndex := uint64(histogramIndex(math.MaxInt64))
+	invalid := map[string]EncodedHistogram{
+		"wrapping delta":   encodeValues(histogramEncodingVersion, 1, 1, 1, 1, math.MaxUint64, 1),
+		"negative delta":   encodeValues(histogramEncodingVersion, 1, 1, 1<<63, 1),
+		"index past max":   encodeValues(histogramEncodingVersion, 1, 1, maxIndex, 1, 1, 1),
+		"delta past max":   encodeValues(histogramEncodingVersion, 1, 1, maxIndex+1, 1),
+		"missing count":    encodeValues(histogramEncodingVersion, 1, 1, 1),
+		"unknown encoding": encodeValues(histogramEncodingVersion+1, 1, 1),
+	}
+	for name, e := range invalid {
+		_, err := DecodeHistogram(e)
+		if err != ErrInvalidHistogram {
+			t.Errorf("%s: expected %v, got %v", name, ErrInvalidHistogram, err)
+		}
+	}
+
+	h, err := DecodeHistogram(encodeValues(histogramEncodingVersion, 1, 1, maxIndex, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count() != 3 {
+		t.Errorf("expected 3 values in the last bucket, got %d", h.Count())
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+)
+
+const (
+	mtuProbeVersion = 1
+
+	// IP and UDP headers which are part of the MTU but not of the datagram
+	IPv4ProbeOverhead = 20 + 8
+	IPv6ProbeOverhead = 40 + 8
+	// the smallest MTU every IPv4 and IPv6 path has to support
+	MinIPv4MTU = 576
+	MinIPv6MTU = 1280
+)
+
+var mtuProbeMagic = []byte("HPMT")
+
+// MTUProbe is the header of the don't-fragment datagrams sent during MTU
+// tests, the rest of the datagram is padding. The receiver answers every
+// probe with an acknowledgement which carries the size it received.
+//
+// Layout: magic(4) version(1) ack(1) id length(1) id sequence(8) size(4) mac(16)
+type MTUProbe struct {
+	TestID   string
+	Sequence uint64
+	Size     int
+	Ack      bool
+}
+
+func MTUProbeHeaderSize(testID string) int {
+	return len(mtuProbeMagic) + 3 + len(testID) + 12 + datagramMACSize
+}
+
+// IsMTUProbe reports if b starts like a probe or acknowledgement
+func IsMTUProbe(b []byte) bool {
+	return len(b) >= len(mtuProbeMagic) && string(b[:len(mtuProbeMagic)]) == string(mtuProbeMagic)
+}
+
+// Encode writes the header to the start of b and returns the header size.
+// The MAC is only set when key is not empty.
+func (p *MTUProbe) Encode(b []byte, key string) (int, error) {
+	size := MTUProbeHeaderSize(p.TestID)
+	if len(p.TestID) > 255 || len(b) < size {
+		return 0, ErrInvalidDatagram
+	}
+	n := copy(b, mtuProbeMagic)
+	b[n] = mtuProbeVersion
+	b[n+1] = 0
+	if p.Ack {
+		b[n+1] = 1
+	}
+	b[n+2] = byte(len(p.TestID))
+	n += 3
+	n += copy(b[n:], p.TestID)
+	binary.BigEndian.PutUint64(b[n:], p.Sequence)
+	binary.BigEndian.PutUint32(b[n+8:], uint32(p.Size))
+	n += 12
+	if key != "" {
+		copy(b[n:n+datagramMACSize], datagramMAC(b[:n], key))
+	} else {
+		clear(b[n : n+datagramMACSize])
+	}
+	return size, nil
+}
+
+// DecodeMTUProbe parses the header of a probe or acknowledgement,
+// the MAC is verified when key is not empty.
+func DecodeMTUProbe(b []byte, key string) (p MTUProbe, err error) {
+	if !IsMTUProbe(b) || len(b) < len(mtuProbeMagic)+3 {
+		return p, ErrInvalidDatagram
+	}
+	n := len(mtuProbeMagic)
+	if b[n] != mtuProbeVersion {
+		return p, ErrInvalidDatagram
+	}
+	p.Ack = b[n+1] == 1
+	idLen := int(b[n+2])
+	n += 3
+	if len(b) < n+idLen+12+datagramMACSize {
+		return p, ErrInvalidDatagram
+	}
+	p.TestID = string(b[n : n+idLen])
+	n += idLen
+	p.Sequence = binary.BigEndian.Uint64(b[n:])
+	p.Size = int(binary.BigEndian.Uint32(b[n+8:]))
+	n += 12
+	if key != "" && !hmac.Equal(b[n:n+datagramMACSize], datagramMAC(b[:n], key)) {
+		return p, ErrInvalidSignature
+	}
+	return p, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Verified payloads are filled with a pseudo-random pattern. The sender
+// announces the pattern as "<seed>/<size>" in the PatternHeader and the
+// CRC32C of one block of the pattern in the ChecksumHeader. Streams
+// repeat the block, so the receiver can regenerate it from the seed
+// and compare every byte it receives. Payloads larger than
+// MaxPatternSize repeat the block as well.
+const (
+	PatternHeader  = "X-Hperf-Pattern"
+	ChecksumHeader = "X-Hperf-Checksum"
+	// MismatchHeader is sent back by the receiver of an upload
+	// with the number of bytes which did not match the pattern.
+	MismatchHeader = "X-Hperf-Mismatch"
+
+	// MaxPatternSize limits the block a peer can ask us to generate
+	MaxPatternSize = 1 << 20
+)
+
+var (
+	ErrPayloadMismatch = errors.New("payload does not match the pattern")
+	errInvalidPattern  = errors.New("invalid payload pattern")
+
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// PayloadPattern is one block of a verified payload
+type PayloadPattern struct {
+	Seed     uint64
+	block    []byte
+	checksum string
+}
+
+// NewPayloadPattern fills buf with the pattern of a random seed
+func NewPayloadPattern(buf []byte) *PayloadPattern {
+	p := &PayloadPattern{
+		Seed:  rand.Uint64(),
+		block: buf[:min(len(buf), MaxPatternSize)],
+	}
+	FillPattern(p.block, p.Seed)
+	for i := len(p.block); i < len(buf); {
+		i += copy(buf[i:], p.block)
+	}
+	p.checksum = patternChecksum(p.block)
+	return p
+}
+
+// ParsePayloadPattern regenerates the pattern announced by a peer and
+// makes sure both sides agree on it by comparing the checksums.
+func ParsePayloadPattern(pattern string, checksum string) (*PayloadPattern, error) {
+	seed, size, ok := strings.Cut(pattern, "/")
+	if !ok {
+		return nil, errInvalidPattern
+	}
+	p := new(PayloadPattern)
+	var err error
+	p.Seed, err = strconv.ParseUint(seed, 10, 64)
+	if err != nil {
+		return nil, errInvalidPattern
+	}
+	n, err := strconv.Atoi(size)
+	if err != nil || n <= 0 || n > MaxPatternSize {
+		return nil, errInvalidPattern
+	}
+	p.block = make([]byte, n)
+	FillPattern(p.block, p.Seed)
+	p.checksum = patternChecksum(p.block)
+	if p.checksum != checksum {
+		return nil, fmt.Errorf("payload pattern checksum %q does not match %q", checksum, p.checksum)
+	}
+	return p, nil
+}
+
+// FillPattern fills b with the xorshift64* sequence of seed
+func FillPattern(b []byte, seed uint64) {
+	// the state of xorshift can not be zero
+	x := seed | 1
+	var word [8]byte
+	for i := 0; i < len(b); i += len(word) {
+		x ^= x >> 12
+		x ^= x << 25
+		x ^= x >> 27
+		binary.LittleEndian.PutUint64(word[:], x*0x2545F4914F6CDD1D)
+		copy(b[i:], word[:])
+	}
+}
+
+func patternChecksum(b []byte) string {
+	return strconv.FormatUint(uint64(crc32.Checksum(b, castagnoli)), 16)
+}
+
+// Header is the value of the PatternHeader
+func (p *PayloadPattern) Header() string {
+	return strconv.FormatUint(p.Seed, 10) + "/" + strconv.Itoa(len(p.block))
+}
+
+// Checksum is the value of the ChecksumHeader
+func (p *PayloadPattern) Checksum() string {
+	return p.checksum
+}
+
+// Size is the length of one block of the pattern
+func (p *PayloadPattern) Size() int {
+	return len(p.block)
+}
+
+// Reader returns an endless reader which repeats the pattern
+func (p *PayloadPattern) Reader() *BlockReader {
+	return NewBlockReader(p.block)
+}
+
+// Verifier returns a writer which compares everything written to it with the pattern
+func (p *PayloadPattern) Verifier() *PayloadVerifier {
+	return &PayloadVerifier{block: p.block}
+}
+
+// BlockReader endlessly repeats a block of payload
+type BlockReader struct {
+	block  []byte
+	offset int
+}
+
+func NewBlockReader(block []byte) *BlockReader {
+	return &BlockReader{block: block}
+}
+
+func (r *BlockReader) Read(b []byte) (n int, err error) {
+	for n < len(b) {
+		c := copy(b[n:], r.block[r.offset:])
+		n += c
+		r.offset = (r.offset + c) % len(r.block)
+	}
+	return n, nil
+}
+
+// PayloadVerifier counts the bytes which differ from the pattern, Write
+// returns ErrPayloadMismatch when the written data contained any of them.
+type PayloadVerifier struct {
+	block     []byte
+	offset    int
+	Bytes     uint64
+	Corrupted uint64
+}
+
+func (v *PayloadVerifier) Write(b []byte) (n int, err error) {
+	n = len(b)
+	corrupted := v.Corrupted
+	for len(b) > 0 {
+		c := min(len(b), len(v.block)-v.offset)
+		expected := v.block[v.offset : v.offset+c]
+		if !bytes.Equal(b[:c], expected) {
+			for i := range expected {
+				if b[i] != expected[i] {
+					v.Corrupted++
+				}
+			}
+		}
+		v.offset = (v.offset + c) % len(v.block)
+		b = b[c:]
+	}
+	v.Bytes += uint64(n)
+	if v.Corrupted > corrupted {
+		return n, ErrPayloadMismatch
+	}
+	return n, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPayloadPatternRepeatsBlock(t *testing.T) {
+	buf := make([]byte, MaxPatternSize*2+100)
+	p := NewPayloadPattern(buf)
+	if p.Size() != MaxPatternSize {
+		t.Fatalf("expected a block of %d bytes, got %d", MaxPatternSize, p.Size())
+	}
+
+	parsed, err := ParsePayloadPattern(p.Header(), p.Checksum())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := parsed.Verifier()
+	_, err = v.Write(buf)
+	if err != nil || v.Corrupted != 0 {
+		t.Fatalf("payload does not match the pattern: %v, %d bytes", err, v.Corrupted)
+	}
+
+	buf[MaxPatternSize+1] ^= 0xff
+	v = parsed.Verifier()
+	_, err = v.Write(buf)
+	if err != ErrPayloadMismatch || v.Corrupted != 1 {
+		t.Errorf("expected one corrupted byte, got %v, %d bytes", err, v.Corrupted)
+	}
+
+	small := make([]byte, 100)
+	p = NewPayloadPattern(small)
+	if p.Size() != 100 || !bytes.Equal(small, p.block) {
+		t.Error("a small payload should be the block")
+	}
+}
+
+func TestParsePayloadPatternLimits(t *testing.T) {
+	for _, h := range []string{"", "1", "x/10", "1/0", "1/-1", "1/1048577"} {
+		_, err := ParsePayloadPattern(h, "0")
+		if err == nil {
+			t.Errorf("%q: expected an error", h)
+		}
+	}
+	p := NewPayloadPattern(make([]byte, 64))
+	_, err := ParsePayloadPattern(p.Header(), "0")
+	if err == nil {
+		t.Error("expected a checksum mismatch")
+	}
+}
+
+func TestPatternContent(t *testing.T) {
+	c, err := ParsePayloadContent(string(ContentPattern))
+	if err != nil || c != ContentPattern {
+		t.Fatalf("unexpected content %q: %v", c, err)
+	}
+	buf := make([]byte, MaxPatternSize+100)
+	c.Fill(buf)
+	if !bytes.Equal(buf[MaxPatternSize:], buf[:100]) {
+		t.Error("pattern content should repeat the pattern block")
+	}
+	if bytes.Equal(buf[:100], make([]byte, 100)) {
+		t.Error("pattern content should not be zeroes")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import "fmt"
+
+// PhaseTiming sums the durations of one request phase in microseconds
+type PhaseTiming struct {
+	Total int64
+	Count int64
+	High  int64
+}
+
+func (p *PhaseTiming) Record(us int64) {
+	p.Total += us
+	p.Count++
+	p.High = max(p.High, us)
+}
+
+func (p *PhaseTiming) Add(o PhaseTiming) {
+	p.Total += o.Total
+	p.Count += o.Count
+	p.High = max(p.High, o.High)
+}
+
+func (p PhaseTiming) Avg() int64 {
+	if p.Count == 0 {
+		return 0
+	}
+	return p.Total / p.Count
+}
+
+// RequestPhases are the timings of HTTP test requests measured with httptrace.
+//
+//   - DNS, Connect and TLS only happen when a new connection is opened
+//   - Write is from getting a connection until the request is written,
+//     which includes the body for uploads
+//   - FirstByte is from the request being written until the first
+//     byte of the response arrives, the time the peer takes to respond
+type RequestPhases struct {
+	DNS         PhaseTiming
+	Connect     PhaseTiming
+	TLS         PhaseTiming
+	Write       PhaseTiming
+	FirstByte   PhaseTiming
+	NewConns    uint64
+	ReusedConns uint64
+}
+
+func (p *RequestPhases) Add(o RequestPhases) {
+	p.DNS.Add(o.DNS)
+	p.Connect.Add(o.Connect)
+	p.TLS.Add(o.TLS)
+	p.Write.Add(o.Write)
+	p.FirstByte.Add(o.FirstByte)
+	p.NewConns += o.NewConns
+	p.ReusedConns += o.ReusedConns
+}
+
+func (p RequestPhases) Empty() bool {
+	return p.NewConns == 0 && p.ReusedConns == 0 && p.Write.Count == 0 && p.FirstByte.Count == 0
+}
+
+// FirstByteAvg is nil safe since data points of non HTTP tests have no phases
+func (p *RequestPhases) FirstByteAvg() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.FirstByte.Avg()
+}
+
+func (p *RequestPhases) ConnectAvg() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.Connect.Avg()
+}
+
+func (p *RequestPhases) ConnectHigh() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.Connect.High
+}
+
+func (p *RequestPhases) NewConnCount() uint64 {
+	if p == nil {
+		return 0
+	}
+	return p.NewConns
+}
+
+func (p RequestPhases) String() string {
+	return fmt.Sprintf("dns:%d connect:%d tls:%d write:%d firstbyte:%d new:%d reused:%d",
+		p.DNS.Avg(), p.Connect.Avg(), p.TLS.Avg(), p.Write.Avg(), p.FirstByte.Avg(), p.NewConns, p.ReusedConns,
+	)
+}
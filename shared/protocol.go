@@ -0,0 +1,102 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ProtocolVersion needs to be increased every time the WebsocketSignal,
+// Config or DP structs change in a way that older builds can not handle.
+const ProtocolVersion = 1
+
+// Version is the hperf build version, it is set by the main package.
+var Version = "0.0.0-dev"
+
+// ServerInfo is sent by the server as part of the first message on
+// every websocket connection.
+type ServerInfo struct {
+	Version         string
+	ProtocolVersion int
+	TestTypes       []TestType
+	Signals         []SignalType
+	Directions      []TestDirection
+}
+
+func LocalServerInfo() *ServerInfo {
+	testTypes := []TestType{
+		RequestTest,
+		StreamTest,
+		UDPTest,
+		TCPTest,
+		ChurnTest,
+	}
+	if mtuProbesSupported {
+		testTypes = append(testTypes, MTUTest)
+	}
+	return &ServerInfo{
+		Version:         Version,
+		ProtocolVersion: ProtocolVersion,
+		TestTypes:       testTypes,
+		Signals: []SignalType{
+			RunTest,
+			ListenTest,
+			ListTests,
+			GetTest,
+			DeleteTests,
+			Ping,
+			Shutdown,
+			StopAllTests,
+			TestStatus,
+			ClockSync,
+		},
+		Directions: []TestDirection{
+			DirectionUpload,
+			DirectionDownload,
+		},
+	}
+}
+
+// CheckCompatibility returns an error if the server can not handle
+// the given signal, or the test type and direction in case of a
+// RunTest signal.
+func CheckCompatibility(info *ServerInfo, signal SignalType, t TestType, d TestDirection) error {
+	if info == nil {
+		return fmt.Errorf("server did not report a protocol version, expected protocol version %d (hperf %s)", ProtocolVersion, Version)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("server protocol version %d (hperf %s) does not match client protocol version %d (hperf %s)",
+			info.ProtocolVersion, info.Version, ProtocolVersion, Version)
+	}
+	if !slices.Contains(info.Signals, signal) {
+		return fmt.Errorf("server (hperf %s) does not support signal %d", info.Version, signal)
+	}
+	if signal == RunTest && !slices.Contains(info.TestTypes, t) {
+		return fmt.Errorf("server (hperf %s) does not support test type %d", info.Version, t)
+	}
+	if signal == RunTest {
+		// servers without direction support only upload
+		for _, v := range d.Directions() {
+			if v != DirectionUpload && !slices.Contains(info.Directions, v) {
+				return fmt.Errorf("server (hperf %s) does not support direction %s", info.Version, v)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxRampSteps limits the number of steps of a ramp schedule
+const MaxRampSteps = 64
+
+// RampStep is one setting of a ramp test, every step runs for
+// Config.RampStepDuration seconds.
+type RampStep struct {
+	Concurrency int
+	PayloadSize int
+}
+
+func (s RampStep) String() string {
+	return strconv.Itoa(s.Concurrency) + ":" + BToString(uint64(s.PayloadSize))
+}
+
+// ParseRamp parses a comma separated ramp schedule, every step is a
+// concurrency with an optional payload size ("1,2,4,8:1MB"). Steps
+// without a payload size use payloadSize.
+func ParseRamp(s string, payloadSize int) (steps []RampStep, err error) {
+	if s == "" {
+		return nil, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		concurrency, size, hasSize := strings.Cut(strings.TrimSpace(v), ":")
+		step := RampStep{PayloadSize: payloadSize}
+		step.Concurrency, err = strconv.Atoi(concurrency)
+		if err != nil || step.Concurrency <= 0 {
+			return nil, fmt.Errorf("Invalid ramp step (%s), expected a concurrency larger than 0 with an optional payload size (8:1MB)", v)
+		}
+		if hasSize {
+			step.PayloadSize, err = ParseSize(size)
+			if err != nil {
+				return nil, err
+			}
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) > MaxRampSteps {
+		return nil, fmt.Errorf("Ramp schedule has %d steps, at most %d are allowed", len(steps), MaxRampSteps)
+	}
+	return steps, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var rateUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	// longest suffixes first so "MiB" is not matched as "B"
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"B", 1},
+}
+
+// cutByteUnit removes the byte unit from v and returns its size, 0 when v has no unit
+func cutByteUnit(v string) (string, float64) {
+	for _, u := range rateUnits {
+		if n, ok := strings.CutSuffix(v, u.suffix); ok {
+			return strings.TrimSpace(n), u.bytes
+		}
+	}
+	return strings.TrimSpace(v), 0
+}
+
+// ParseSize parses a number of bytes with an optional unit (32000, 32KB, 1MiB)
+func ParseSize(s string) (int, error) {
+	v, unit := cutByteUnit(s)
+	size, err := strconv.ParseFloat(v, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("Invalid size (%s), expected bytes with an optional unit (32000, 32KB, 1MiB)", s)
+	}
+	return int(size * max(unit, 1)), nil
+}
+
+// ParseRate returns the requests per second of a rate given as requests per
+// second ("500") or as bytes per second ("100MB/s", "1GiB"), bytes are turned
+// into requests of payloadSize bytes.
+func ParseRate(s string, payloadSize int) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, unit := cutByteUnit(strings.TrimSuffix(s, "/s"))
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("Invalid rate (%s), expected requests per second (500) or bytes per second (100MB/s)", s)
+	}
+	if unit == 0 {
+		return rate, nil
+	}
+	if payloadSize <= 0 {
+		return 0, fmt.Errorf("Rate (%s) in bytes per second needs a payload size larger than 0", s)
+	}
+	return rate * unit / float64(payloadSize), nil
+}
@@ -40,9 +40,22 @@ type WebsocketSignal struct {
 
 	// Type specific fields
 	Data      []byte
+	Reason    string
 	Config    Config
 	DataPoint *DataReponseToClient
 	TestList  []TestInfo
+	Statuses  []TestStatusReport
+	Clock     *ClockSample
+
+	// Protocol negotiation, the server sends Info in the first
+	// message and the client sends ProtocolVersion with every signal.
+	Info            *ServerInfo
+	ProtocolVersion int
+
+	// Authentication, only set when an auth key is configured
+	Timestamp int64
+	Nonce     string
+	Signature string
 }
 
 type TestInfo struct {
@@ -60,11 +73,30 @@ type TestOutput struct {
 	RMSH     int64
 	TTFBL    int64
 	TTFBH    int64
+	RXC      uint64
+	LC       uint64
+	ROC      uint64
+	DUPC     uint64
+	JL       int64
+	JH       int64
+	RTTH     int64
+	RTC      uint64
 	DP       int
 	ML       int
 	MH       int
 	CL       int
 	CH       int
+	CCH      int
+	PCH      int
+	CPSH     uint64
+	CPSL     uint64
+	CTH      int64
+	PEC      uint64
+	TOC      uint64
+	RFC      uint64
+	RSC      uint64
+	MTUL     int
+	MTUH     int
 }
 
 type (
@@ -77,6 +109,8 @@ type (
 const (
 	DataPoint FilePrefix = iota
 	ErrorPoint
+	MetadataPoint
+	HostPoint
 )
 
 func (f FilePrefix) String() []byte {
@@ -92,16 +126,22 @@ const (
 	DeleteTests
 	Ping
 	Pong
-	Exit
+	Shutdown
 	StopAllTests
 	Stats
 	Done
+	TestStatus
+	ClockSync
 )
 
 const (
 	Unknown TestType = iota
 	RequestTest
 	StreamTest
+	UDPTest
+	TCPTest
+	ChurnTest
+	MTUTest
 )
 
 const (
@@ -121,6 +161,7 @@ type DP struct {
 	Created           time.Time
 	Local             string
 	Remote            string
+	Direction         TestDirection
 	RMSH              int64
 	RMSL              int64
 	TTFBH             int64
@@ -133,21 +174,206 @@ type DP struct {
 	MemoryUsedPercent int
 	CPUUsedPercent    int
 
+	// CPU usage during the interval in percent, the system wide split and
+	// the busiest core are only reported on linux. ProcessCPU is the usage
+	// of hperf itself in percent of all cores and ProcessRSS its resident
+	// memory in bytes. CPUSaturated is set when a core or the hperf process
+	// is close to 100% of what it can use, results are then likely limited
+	// by the CPU. The usage of every core is in the HostDP of the interval.
+	CPUUser       int
+	CPUSystem     int
+	CPUSoftIRQ    int
+	CPUIOWait     int
+	CPUCoreHigh   int
+	CPUCoreHighID int
+	ProcessCPU    int
+	ProcessRSS    uint64
+	CPUSaturated  bool
+
+	// UDP tests only, measured on packets received from Remote.
+	// Jitter is the RFC 3550 interarrival jitter in microseconds.
+	PacketsReceived  uint64
+	PacketsLost      uint64
+	PacketsReordered uint64
+	PacketsDuplicate uint64
+	Jitter           int64
+
+	// Kernel TCP_INFO of the open test connections (linux only). RTT, RTTVar
+	// (microseconds) and Cwnd (segments) are averaged, Retransmits are counted
+	// during the interval and the rest is summed over all connections.
+	TCPConnections  int
+	TCPRTT          int64
+	TCPRTTVar       int64
+	TCPRetransmits  uint64
+	TCPLost         uint64
+	TCPCwnd         uint64
+	TCPDeliveryRate uint64
+	TCPPacingRate   uint64
+
+	// TCP tests with socket options only, what the kernel applied to the
+	// last connection to Remote and to the last connection accepted from it.
+	Socket         *SocketState `json:",omitempty"`
+	AcceptedSocket *SocketState `json:",omitempty"`
+
+	// HTTP tests only, the phases of the requests during the interval
+	Phases *RequestPhases `json:",omitempty"`
+
+	// HTTP tests only, new connections per second and failed requests by the
+	// reason the connection failed. Connection churn tests open a new
+	// connection for every request.
+	ConnsPerSecond    uint64
+	ConnPortExhausted uint64
+	ConnTimeouts      uint64
+	ConnRefused       uint64
+	ConnReset         uint64
+	ConnOtherErrors   uint64
+
+	// Open-loop tests only, the requests per second the test should send
+	// and did send, and the highest delay of a request behind its
+	// schedule in microseconds.
+	TargetRate float64
+	Rate       float64
+	SendLag    int64
+
+	// MTU tests only, the largest packet which reached the peer with the
+	// don't fragment bit set, the MTU of the interface it was sent from
+	// and the path MTU the kernel learned from ICMP, 0 when unknown.
+	PathMTU      int
+	InterfaceMTU int
+	KernelMTU    int
+
+	// Tests with clock sync only, the offset of the peer clock, its drift
+	// and the average RTT of the probes during the interval. OneWayOut and
+	// OneWayBack split the RTT using the offset, they are derived from the
+	// estimate and not measured. Only one data point per peer and interval
+	// has them. Offsets and delays are in microseconds.
+	ClockSamples uint64
+	ClockOffset  int64
+	ClockDrift   float64
+	ClockRTT     int64
+	OneWayOut    int64
+	OneWayBack   int64
+
+	// Ramp tests only, the step of the schedule and its settings
+	RampStep    int
+	Concurrency int
+	PayloadSize int
+
+	// HTTP tests with payload verification only, payloads which did not
+	// match the pattern of the sender and the number of corrupted bytes.
+	PayloadMismatches uint64
+	CorruptedBytes    uint64
+
+	// Every request duration and TTFB recorded during the interval, in microseconds
+	RMSHistogram  EncodedHistogram `json:",omitempty"`
+	TTFBHistogram EncodedHistogram `json:",omitempty"`
+	// Every successful TCP connect during the interval, in microseconds
+	ConnectHistogram EncodedHistogram `json:",omitempty"`
+
 	// Client only
 	Received time.Time `json:"-"`
 }
 
+// CoreStats is the usage of one core during an interval in percent
+type CoreStats struct {
+	Core    int
+	User    int
+	System  int
+	SoftIRQ int
+	IOWait  int
+	Busy    int
+}
+
+func (c CoreStats) String() string {
+	return fmt.Sprintf("cpu%d(busy:%d usr:%d sys:%d sirq:%d iow:%d)", c.Core, c.Busy, c.User, c.System, c.SoftIRQ, c.IOWait)
+}
+
+// NICStats holds the counters of one network interface as found in /proc/net/dev
+type NICStats struct {
+	Interface string
+	RXBytes   uint64
+	RXPackets uint64
+	RXErrors  uint64
+	RXDropped uint64
+	RXFifo    uint64
+	RXFrame   uint64
+	TXBytes   uint64
+	TXPackets uint64
+	TXErrors  uint64
+	TXDropped uint64
+	TXFifo    uint64
+}
+
+// Add sums the counters of n into s
+func (s *NICStats) Add(n NICStats) {
+	s.RXBytes += n.RXBytes
+	s.RXPackets += n.RXPackets
+	s.RXErrors += n.RXErrors
+	s.RXDropped += n.RXDropped
+	s.RXFifo += n.RXFifo
+	s.RXFrame += n.RXFrame
+	s.TXBytes += n.TXBytes
+	s.TXPackets += n.TXPackets
+	s.TXErrors += n.TXErrors
+	s.TXDropped += n.TXDropped
+	s.TXFifo += n.TXFifo
+}
+
+func (s NICStats) String() string {
+	return fmt.Sprintf("%s(rx:%d/%d err:%d drop:%d fifo:%d frame:%d tx:%d/%d err:%d drop:%d fifo:%d)",
+		s.Interface,
+		s.RXBytes, s.RXPackets, s.RXErrors, s.RXDropped, s.RXFifo, s.RXFrame,
+		s.TXBytes, s.TXPackets, s.TXErrors, s.TXDropped, s.TXFifo,
+	)
+}
+
+// TestMetadata is written to the start of every result file and again
+// when the file is closed, the last record for a server is the most
+// complete one.
+type TestMetadata struct {
+	TestID          string
+	Server          string
+	Hostname        string
+	HperfVersion    string
+	ProtocolVersion int
+	Config          Config
+	Created         time.Time
+	Started         time.Time
+	Ended           time.Time
+	// ClockOffset is how far the server clock was ahead of the client
+	// in microseconds, it is set by the client when the timestamps of
+	// the server were corrected.
+	ClockOffset int64
+}
+
 type DataReponseToClient struct {
 	DPS    []DP
 	Errors []TError
+	Hosts  []HostDP `json:",omitempty"`
+}
+
+// HostDP holds the stats of a server which are the same for all of its
+// links, it is created once per interval next to the data points.
+type HostDP struct {
+	TestID  string
+	Local   string
+	Created time.Time
+
+	// the usage of every core in percent (linux only)
+	Cores []CoreStats `json:",omitempty"`
+
+	// Counters of the monitored network interfaces (linux only), these are
+	// the changes since the previous interval of the test. DroppedPackets
+	// of the data points is the sum of their RX and TX drops.
+	NICs []NICStats `json:",omitempty"`
 }
 
 type Config struct {
 	Debug          bool          `json:"Debug"`
 	Port           string        `json:"Port"`
 	Concurrency    int           `json:"Concurrency"`
-	PayloadSize    int           `json:"PayloadMB"`
-	BufferSize     int           `json:"BufferKB"`
+	PayloadSize    int           `json:"PayloadBytes"`
+	BufferSize     int           `json:"BufferBytes"`
 	Duration       int           `json:"Duration"`
 	RequestDelay   int           `json:"RequestDelay"`
 	Hosts          []string      `json:"Hosts"`
@@ -158,6 +384,31 @@ type Config struct {
 	Insecure       bool          `json:"Insecure"`
 	TestType       TestType      `json:"TestType"`
 	File           string        `json:"File"`
+	DrainWait      bool          `json:"DrainWait"`
+	Direction      TestDirection `json:"Direction"`
+	PacketRate     int           `json:"PacketRate"`
+	TCPPort        string        `json:"TCPPort"`
+	Verify         bool          `json:"Verify"`
+	// ClockSync makes servers exchange clock probes with their peers
+	// to estimate the clock offset and the one-way delay.
+	ClockSync bool `json:"ClockSync"`
+	// Rate is the number of requests per second sent to every peer on a
+	// fixed schedule, tests are closed-loop when it is 0.
+	Rate float64 `json:"Rate"`
+	// Ramp steps the concurrency and payload size through a schedule
+	// during one test, every step runs for RampStepDuration seconds.
+	Ramp             []RampStep `json:"Ramp"`
+	RampStepDuration int        `json:"RampStepDuration"`
+	// Sweep is the ID of the parameter sweep the test is part of
+	Sweep string `json:"Sweep"`
+
+	PayloadContent PayloadContent `json:"PayloadContent"`
+	Socket         SocketOptions  `json:"Socket"`
+
+	// Fingerprints of peer certificates, used by the servers when
+	// connecting to each other and by the client when connecting
+	// to the servers.
+	TLSFingerprints []string `json:"TLSFingerprints"`
 	// AllowLocalInterface bool          `json:"AllowLocalInterfaces"`
 
 	// Client Only
@@ -168,6 +419,8 @@ type Config struct {
 	Sort         SortType `json:"-"`
 	Micro        bool     `json:"-"`
 	HostFilter   string   `json:"-"`
+	TLSCA        string   `json:"-"`
+	AuthKey      string   `json:"-"`
 }
 
 func INFO(items ...any) {
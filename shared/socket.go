@@ -0,0 +1,178 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TestHeader tells the peer which test a request belongs to, it is
+	// signed and the peer applies the socket options of the test.
+	TestHeader = "X-Hperf-Test"
+
+	MaxSocketBuffer = 1 << 30
+	// the kernel limits of TCP_MAXSEG and of congestion control names
+	minMaxSegment     = 88
+	maxMaxSegment     = 32767
+	maxCongestionName = 15
+)
+
+// SocketOptions are applied to the TCP sockets of a test on the dialing
+// and on the accepting side, zero values keep the kernel defaults.
+type SocketOptions struct {
+	SendBuffer    int    `json:"SendBuffer"`
+	ReceiveBuffer int    `json:"ReceiveBuffer"`
+	Congestion    string `json:"Congestion"`
+	MaxSegment    int    `json:"MaxSegment"`
+	// Nagle clears TCP_NODELAY, which Go sets on every TCP connection
+	Nagle bool `json:"Nagle"`
+	// TOS is the IPv4 TOS byte or the IPv6 traffic class, DSCP is in the top 6 bits
+	TOS int `json:"TOS"`
+}
+
+// SocketState is what the kernel reports for a socket once the options
+// were applied. Linux doubles the buffer sizes to leave room for its
+// bookkeeping and reports the current segment size.
+type SocketState struct {
+	SendBuffer    int
+	ReceiveBuffer int
+	Congestion    string
+	MaxSegment    int
+	NoDelay       bool
+	TOS           int
+}
+
+// dscpClasses are the DSCP code points accepted by ParseTOS
+var dscpClasses = map[string]int{
+	"be": 0, "le": 1, "ef": 46, "va": 44,
+	"cs0": 0, "cs1": 8, "cs2": 16, "cs3": 24, "cs4": 32, "cs5": 40, "cs6": 48, "cs7": 56,
+	"af11": 10, "af12": 12, "af13": 14,
+	"af21": 18, "af22": 20, "af23": 22,
+	"af31": 26, "af32": 28, "af33": 30,
+	"af41": 34, "af42": 36, "af43": 38,
+}
+
+// ParseTOS parses a TOS byte (184, 0xb8) or a DSCP class (ef, af41, cs1)
+func ParseTOS(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	dscp, ok := dscpClasses[strings.ToLower(s)]
+	if ok {
+		return dscp << 2, nil
+	}
+	tos, err := strconv.ParseInt(s, 0, 64)
+	if err != nil || tos < 0 || tos > 255 {
+		return 0, fmt.Errorf("Invalid TOS (%s), expected a byte (184, 0xb8) or a DSCP class (ef, af41, cs1)", s)
+	}
+	return int(tos), nil
+}
+
+// IsSet is false when every option keeps the kernel default
+func (o SocketOptions) IsSet() bool {
+	return o != SocketOptions{}
+}
+
+func (o SocketOptions) Validate() error {
+	if o.SendBuffer < 0 || o.SendBuffer > MaxSocketBuffer || o.ReceiveBuffer < 0 || o.ReceiveBuffer > MaxSocketBuffer {
+		return fmt.Errorf("Invalid socket buffer size, expected at most %s", BToString(MaxSocketBuffer))
+	}
+	if o.MaxSegment != 0 && (o.MaxSegment < minMaxSegment || o.MaxSegment > maxMaxSegment) {
+		return fmt.Errorf("Invalid maximum segment size (%d), expected %d to %d bytes", o.MaxSegment, minMaxSegment, maxMaxSegment)
+	}
+	if len(o.Congestion) > maxCongestionName || strings.ContainsFunc(o.Congestion, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) {
+		return fmt.Errorf("Invalid congestion control (%s), expected the name of a kernel module like cubic, bbr or reno", o.Congestion)
+	}
+	if o.TOS < 0 || o.TOS > 255 {
+		return fmt.Errorf("Invalid TOS (%d), expected 0 to 255", o.TOS)
+	}
+	return nil
+}
+
+// NotApplied lists the options the kernel did not apply to a socket
+func (o SocketOptions) NotApplied(s SocketState) (list []string) {
+	if s.SendBuffer < o.SendBuffer {
+		list = append(list, "send buffer")
+	}
+	if s.ReceiveBuffer < o.ReceiveBuffer {
+		list = append(list, "receive buffer")
+	}
+	if o.Congestion != "" && s.Congestion != o.Congestion {
+		list = append(list, "congestion control")
+	}
+	if o.MaxSegment != 0 && s.MaxSegment > o.MaxSegment {
+		list = append(list, "segment size")
+	}
+	if s.NoDelay == o.Nagle {
+		list = append(list, "nodelay")
+	}
+	// the kernel manages the ECN bits of TCP sockets
+	if s.TOS>>2 != o.TOS>>2 {
+		list = append(list, "tos")
+	}
+	return
+}
+
+// AfterHandshake lists the requested options which accepted connections only
+// get after the handshake. The segment size and the window scale were already
+// negotiated by then, so they have little or no effect on the transfer.
+func (o SocketOptions) AfterHandshake() (list []string) {
+	if o.ReceiveBuffer > 0 {
+		list = append(list, "rcvbuf")
+	}
+	if o.MaxSegment > 0 {
+		list = append(list, "mss")
+	}
+	return
+}
+
+func (o SocketOptions) String() string {
+	list := make([]string, 0)
+	if o.SendBuffer > 0 {
+		list = append(list, "sndbuf "+BToString(uint64(o.SendBuffer)))
+	}
+	if o.ReceiveBuffer > 0 {
+		list = append(list, "rcvbuf "+BToString(uint64(o.ReceiveBuffer)))
+	}
+	if o.Congestion != "" {
+		list = append(list, o.Congestion)
+	}
+	if o.MaxSegment > 0 {
+		list = append(list, "mss "+strconv.Itoa(o.MaxSegment))
+	}
+	if o.Nagle {
+		list = append(list, "nagle")
+	}
+	if o.TOS > 0 {
+		list = append(list, FormatTOS(o.TOS))
+	}
+	if len(list) == 0 {
+		return "default"
+	}
+	return strings.Join(list, ", ")
+}
+
+// FormatTOS shows the TOS byte with its DSCP code point
+func FormatTOS(tos int) string {
+	return fmt.Sprintf("0x%02x (dscp %d)", tos, tos>>2)
+}
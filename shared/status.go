@@ -0,0 +1,64 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import "time"
+
+type TestState int
+
+const (
+	TestQueued TestState = iota
+	TestRunning
+	TestFinished
+	TestFailed
+	TestStopped
+)
+
+func (s TestState) String() string {
+	switch s {
+	case TestQueued:
+		return "queued"
+	case TestRunning:
+		return "running"
+	case TestFinished:
+		return "finished"
+	case TestFailed:
+		return "failed"
+	case TestStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Done returns true if the test will not change state anymore
+func (s TestState) Done() bool {
+	return s == TestFinished || s == TestFailed || s == TestStopped
+}
+
+// TestStatusReport is returned by the server for every test
+// in response to a TestStatus signal.
+type TestStatusReport struct {
+	ID      string
+	Type    TestType
+	State   TestState
+	Created time.Time
+	Started time.Time
+	Ended   time.Time
+	Cause   string
+}
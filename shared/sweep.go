@@ -0,0 +1,93 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxSweepTests limits the number of combinations a sweep runs
+const MaxSweepTests = 256
+
+// SweepPoint is one combination of a parameter sweep,
+// it runs as a test of its own.
+type SweepPoint struct {
+	Concurrency int
+	PayloadSize int
+	BufferSize  int
+}
+
+func (p SweepPoint) String() string {
+	return fmt.Sprintf("concurrency %d, payload %s, buffer %s", p.Concurrency, BToString(uint64(p.PayloadSize)), BToString(uint64(p.BufferSize)))
+}
+
+// SweepTestID is the ID of the test which runs combination i of a sweep
+func SweepTestID(parent string, i int) string {
+	return parent + "-" + strconv.Itoa(i+1)
+}
+
+// ParseSweepCounts parses a comma separated list of counts ("1,4,16"),
+// the list is def when s is empty.
+func ParseSweepCounts(s string, def int) (list []int, err error) {
+	if s == "" {
+		return []int{def}, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("Invalid sweep value (%s), expected a number larger than 0", v)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+// ParseSweepSizes parses a comma separated list of sizes ("32KB,1MB"),
+// the list is def when s is empty.
+func ParseSweepSizes(s string, def int) (list []int, err error) {
+	if s == "" {
+		return []int{def}, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		n, err := ParseSize(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+// SweepPoints returns the Cartesian product of the values, the
+// concurrency changes fastest and the buffer size slowest.
+func SweepPoints(concurrency, payloadSizes, bufferSizes []int) (points []SweepPoint, err error) {
+	total := len(concurrency) * len(payloadSizes) * len(bufferSizes)
+	if total > MaxSweepTests {
+		return nil, fmt.Errorf("Sweep has %d combinations, at most %d are allowed", total, MaxSweepTests)
+	}
+	for _, b := range bufferSizes {
+		for _, p := range payloadSizes {
+			for _, c := range concurrency {
+				points = append(points, SweepPoint{Concurrency: c, PayloadSize: p, BufferSize: b})
+			}
+		}
+	}
+	return points, nil
+}
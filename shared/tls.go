@@ -0,0 +1,196 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	SelfSignedCertFile = "public.crt"
+	SelfSignedKeyFile  = "private.key"
+)
+
+// NewTLSConfig creates a client side TLS config. When fingerprints are
+// provided the peer certificate is pinned instead of being verified
+// against the system or custom CA pool.
+func NewTLSConfig(caFile string, fingerprints []string) (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read CA bundle (%s): %s", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("No certificates found in CA bundle (%s)", caFile)
+		}
+		tc.RootCAs = pool
+	}
+
+	if len(fingerprints) > 0 {
+		pins := make(map[string]struct{}, len(fingerprints))
+		for _, v := range fingerprints {
+			pins[NormalizeFingerprint(v)] = struct{}{}
+		}
+		// Verification is done by VerifyPeerCertificate below, the chain
+		// is not verified since pinned certificates are often self-signed.
+		tc.InsecureSkipVerify = true
+		tc.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("Peer did not present a certificate")
+			}
+			fp := Fingerprint(rawCerts[0])
+			if _, ok := pins[fp]; !ok {
+				return fmt.Errorf("Peer certificate fingerprint (%s) is not pinned", fp)
+			}
+			return nil
+		}
+	}
+
+	return tc, nil
+}
+
+// Fingerprint returns the hex encoded SHA-256 of a DER encoded certificate.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeFingerprint accepts fingerprints with or without colons and in
+// any case, as printed by openssl or by 'hperf server'.
+func NormalizeFingerprint(fp string) string {
+	fp = strings.TrimSpace(fp)
+	fp = strings.TrimPrefix(strings.ToLower(fp), "sha256:")
+	return strings.ReplaceAll(fp, ":", "")
+}
+
+// ParseFingerprints splits a comma separated list of fingerprints.
+func ParseFingerprints(list string) (fps []string) {
+	fps = make([]string, 0)
+	for _, v := range strings.Split(list, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		fps = append(fps, NormalizeFingerprint(v))
+	}
+	return
+}
+
+// CertificateFingerprint loads the first certificate in a PEM file and
+// returns its fingerprint.
+func CertificateFingerprint(certFile string) (string, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("No certificate found in %s", certFile)
+	}
+	return Fingerprint(block.Bytes), nil
+}
+
+// GenerateSelfSignedCert writes a self-signed certificate and key to dir.
+// If both files already exist they are reused so the fingerprint stays the
+// same between restarts.
+func GenerateSelfSignedCert(dir string, hosts []string) (certFile, keyFile string, err error) {
+	certFile = filepath.Join(dir, SelfSignedCertFile)
+	keyFile = filepath.Join(dir, SelfSignedKeyFile)
+
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certErr == nil && keyErr == nil {
+		return
+	}
+
+	err = os.MkdirAll(dir, 0o777)
+	if err != nil {
+		return
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"hperf"}, CommonName: "hperf"},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	template.DNSNames = append(template.DNSNames, "localhost")
+	template.IPAddresses = append(template.IPAddresses, net.ParseIP("127.0.0.1"))
+	for _, h := range hosts {
+		if h == "" {
+			continue
+		}
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return
+	}
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return
+}